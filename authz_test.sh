@@ -0,0 +1,270 @@
+#!/bin/bash
+
+# ==============================================================================
+#           视频平台后端 - 权限 (所有者 / 管理员 / 审核员) 接口测试脚本
+# ==============================================================================
+#
+# 对运行中的 API 逐个请求 cmd/api/main.go 中修改视频和评论的路由，检查 403 / 404 是否一致:
+#   - 看不到的视频 (别人未公开的视频) 一律 404
+#   - 看得到但无权操作 (别人已公开的视频) 一律 403
+# 权限规则本身 (用户 × 可见性 × 操作) 和错误到状态码的映射由 go test 覆盖:
+# internal/service/policy_test.go、internal/api/handler/policy_common_test.go
+#
+# 使用方法:
+# 1. 确保 API Server 正在运行，并安装了 `curl` 和 `jq`。
+# 2. (可选) 设置 ONLINE_VIDEO_ID 为一个 "online" 状态的视频 ID，用于测试 403 场景。
+# 3. (可选) 设置 ADMIN_EMAIL/ADMIN_PASSWORD、AUDITOR_EMAIL/AUDITOR_PASSWORD，
+#    对应账号需要事先在数据库里把 role 改为 admin / auditor。
+# 4. 运行 `bash authz_test.sh`，全部通过时退出码为 0。
+#
+# ==============================================================================
+
+API_BASE_URL="http://localhost:8000/api/v1"
+ONLINE_VIDEO_ID="${ONLINE_VIDEO_ID:-}"
+FAILED=0
+
+# --- 辅助函数 ---
+function print_header() {
+    echo ""
+    echo "=================================================="
+    echo "  $1"
+    echo "=================================================="
+}
+
+# expect_status <描述> <期望状态码> <实际状态码>
+function expect_status() {
+    if [ "$2" == "$3" ]; then
+        echo "PASS> $1 (HTTP $3)"
+    else
+        echo "FAIL> $1: expected HTTP $2, got HTTP $3"
+        FAILED=$((FAILED + 1))
+    fi
+}
+
+# expect_allowed <描述> <实际状态码>: 只要求没有被权限层拦截 (非 401/403/404)
+function expect_allowed() {
+    case "$2" in
+        401|403|404) echo "FAIL> $1: blocked by policy (HTTP $2)"; FAILED=$((FAILED + 1)) ;;
+        *) echo "PASS> $1 (HTTP $2)" ;;
+    esac
+}
+
+# status <METHOD> <URL> <TOKEN> [curl 其他参数...]: 只输出 HTTP 状态码
+function status() {
+    local method=$1 url=$2 token=$3
+    shift 3
+    curl -s -o /dev/null -w "%{http_code}" -X "$method" "$url" -H "Authorization: Bearer $token" "$@"
+}
+
+# register_and_login <昵称>: 注册一个新用户并输出 token
+function register_and_login() {
+    local email="authz-$1-$(date +%s%N)@example.com"
+    curl -s -X POST "$API_BASE_URL/users/register" -H "Content-Type: application/json" \
+        -d "{\"nickname\": \"$1\", \"email\": \"$email\", \"password\": \"password123\"}" > /dev/null
+    curl -s -X POST "$API_BASE_URL/users/login" -H "Content-Type: application/json" \
+        -d "{\"email\": \"$email\", \"password\": \"password123\"}" | jq -r '.token'
+}
+
+function login() {
+    curl -s -X POST "$API_BASE_URL/users/login" -H "Content-Type: application/json" \
+        -d "{\"email\": \"$1\", \"password\": \"$2\"}" | jq -r '.token'
+}
+
+# ==============================================================================
+#                              准备数据
+# ==============================================================================
+
+print_header "0. 准备用户和视频"
+
+OWNER_TOKEN=$(register_and_login "owner")
+OTHER_TOKEN=$(register_and_login "other")
+if [ -z "$OWNER_TOKEN" ] || [ "$OWNER_TOKEN" == "null" ] || [ -z "$OTHER_TOKEN" ] || [ "$OTHER_TOKEN" == "null" ]; then
+    echo "无法注册/登录测试用户，请检查 API Server 是否正在运行。"
+    exit 1
+fi
+
+# 单文件上传的视频 (状态 uploading，只有所有者可见)
+SINGLE_ID=$(curl -s -X POST "$API_BASE_URL/videos/upload/initiate" \
+    -H "Authorization: Bearer $OWNER_TOKEN" -H "Content-Type: application/json" \
+    -d '{"file_name": "authz.mp4", "title": "authz single"}' | jq -r '.video_id')
+
+# 分片上传的视频
+MULTIPART_ID=$(curl -s -X POST "$API_BASE_URL/videos/upload/multipart/initiate" \
+    -H "Authorization: Bearer $OWNER_TOKEN" -H "Content-Type: application/json" \
+    -d '{"file_name": "authz.mp4", "title": "authz multipart", "file_size": 1048576}' | jq -r '.video_id')
+
+# tus 上传的视频
+TUS_LOCATION=$(curl -s -D - -o /dev/null -X POST "$API_BASE_URL/videos/upload/tus" \
+    -H "Authorization: Bearer $OWNER_TOKEN" -H "Tus-Resumable: 1.0.0" -H "Upload-Length: 1048576" \
+    -H "Upload-Metadata: filename $(echo -n authz.mp4 | base64)" | grep -i '^Location:' | awk '{print $2}' | tr -d '\r')
+TUS_ID=$(basename "$TUS_LOCATION")
+
+echo "INFO> single=$SINGLE_ID multipart=$MULTIPART_ID tus=$TUS_ID"
+
+TUS_HEADERS=(-H "Tus-Resumable: 1.0.0")
+
+# ==============================================================================
+#                    1. 其他用户访问未公开视频: 一律 404
+# ==============================================================================
+
+print_header "1. 其他用户 -> 别人未公开的视频 (期望 404)"
+
+expect_status "POST /videos/upload/complete" 404 \
+    "$(status POST "$API_BASE_URL/videos/upload/complete" "$OTHER_TOKEN" -H "Content-Type: application/json" -d "{\"video_id\": $SINGLE_ID}")"
+expect_status "GET /videos/upload/multipart/:id/parts" 404 \
+    "$(status GET "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID/parts" "$OTHER_TOKEN")"
+expect_status "GET /videos/upload/multipart/:id/parts/:part" 404 \
+    "$(status GET "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID/parts/1" "$OTHER_TOKEN")"
+expect_status "DELETE /videos/upload/multipart/:id" 404 \
+    "$(status DELETE "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID" "$OTHER_TOKEN")"
+expect_status "HEAD /videos/upload/tus/:id" 404 \
+    "$(status HEAD "$API_BASE_URL/videos/upload/tus/$TUS_ID" "$OTHER_TOKEN" "${TUS_HEADERS[@]}")"
+expect_status "PATCH /videos/upload/tus/:id" 404 \
+    "$(status PATCH "$API_BASE_URL/videos/upload/tus/$TUS_ID" "$OTHER_TOKEN" "${TUS_HEADERS[@]}" \
+        -H "Upload-Offset: 0" -H "Content-Type: application/offset+octet-stream" --data-binary "x")"
+expect_status "DELETE /videos/upload/tus/:id" 404 \
+    "$(status DELETE "$API_BASE_URL/videos/upload/tus/$TUS_ID" "$OTHER_TOKEN" "${TUS_HEADERS[@]}")"
+expect_status "DELETE /videos/:id" 404 \
+    "$(status DELETE "$API_BASE_URL/videos/$SINGLE_ID" "$OTHER_TOKEN")"
+expect_status "GET /videos/:id/import" 404 \
+    "$(status GET "$API_BASE_URL/videos/$SINGLE_ID/import" "$OTHER_TOKEN")"
+expect_status "GET /videos/:id/covers" 404 \
+    "$(status GET "$API_BASE_URL/videos/$SINGLE_ID/covers" "$OTHER_TOKEN")"
+expect_status "POST /videos/:id/cover/upload" 404 \
+    "$(status POST "$API_BASE_URL/videos/$SINGLE_ID/cover/upload" "$OTHER_TOKEN")"
+expect_status "PUT /videos/:id/cover" 404 \
+    "$(status PUT "$API_BASE_URL/videos/$SINGLE_ID/cover" "$OTHER_TOKEN" -H "Content-Type: application/json" -d '{"candidate_id": 1}')"
+expect_status "PUT /videos/:id/subtitles/:language" 404 \
+    "$(status PUT "$API_BASE_URL/videos/$SINGLE_ID/subtitles/en" "$OTHER_TOKEN" -F "file=@/dev/null;filename=en.srt")"
+expect_status "DELETE /videos/:id/subtitles/:language" 404 \
+    "$(status DELETE "$API_BASE_URL/videos/$SINGLE_ID/subtitles/en" "$OTHER_TOKEN")"
+expect_status "GET /videos/:id/key" 404 \
+    "$(status GET "$API_BASE_URL/videos/$SINGLE_ID/key" "$OTHER_TOKEN")"
+expect_status "GET /videos/:id/key (匿名，伪造的播放令牌)" 401 \
+    "$(curl -s -o /dev/null -w "%{http_code}" "$API_BASE_URL/videos/$SINGLE_ID/key?token=forged")"
+expect_status "GET /videos/:id/key (匿名，没有播放令牌)" 401 \
+    "$(curl -s -o /dev/null -w "%{http_code}" "$API_BASE_URL/videos/$SINGLE_ID/key")"
+expect_status "POST /videos/:id/comments" 404 \
+    "$(status POST "$API_BASE_URL/videos/$SINGLE_ID/comments" "$OTHER_TOKEN" -H "Content-Type: application/json" -d '{"content": "hi"}')"
+expect_status "POST /videos/:id/comments (不存在的视频)" 404 \
+    "$(status POST "$API_BASE_URL/videos/999999999/comments" "$OTHER_TOKEN" -H "Content-Type: application/json" -d '{"content": "hi"}')"
+
+# ==============================================================================
+#                    2. 所有者访问自己的视频: 不会被权限层拦截
+# ==============================================================================
+
+print_header "2. 所有者 -> 自己的视频 (不应返回 403/404)"
+
+expect_allowed "GET /videos/upload/multipart/:id/parts" \
+    "$(status GET "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID/parts" "$OWNER_TOKEN")"
+expect_allowed "GET /videos/upload/multipart/:id/parts/:part" \
+    "$(status GET "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID/parts/1" "$OWNER_TOKEN")"
+expect_allowed "HEAD /videos/upload/tus/:id" \
+    "$(status HEAD "$API_BASE_URL/videos/upload/tus/$TUS_ID" "$OWNER_TOKEN" "${TUS_HEADERS[@]}")"
+# 不是导入的视频，权限检查通过后返回 404 (没有导入任务)
+expect_status "GET /videos/:id/import (非导入视频)" 404 \
+    "$(status GET "$API_BASE_URL/videos/$SINGLE_ID/import" "$OWNER_TOKEN")"
+# 文件还没上传，完成上传会被服务端校验拒绝 (409)，但不是权限错误
+expect_status "POST /videos/upload/complete (文件未上传)" 409 \
+    "$(status POST "$API_BASE_URL/videos/upload/complete" "$OWNER_TOKEN" -H "Content-Type: application/json" -d "{\"video_id\": $SINGLE_ID}")"
+expect_allowed "POST /videos/:id/comments" \
+    "$(status POST "$API_BASE_URL/videos/$SINGLE_ID/comments" "$OWNER_TOKEN" -H "Content-Type: application/json" -d '{"content": "owner note"}')"
+expect_allowed "DELETE /videos/upload/tus/:id" \
+    "$(status DELETE "$API_BASE_URL/videos/upload/tus/$TUS_ID" "$OWNER_TOKEN" "${TUS_HEADERS[@]}")"
+expect_allowed "DELETE /videos/upload/multipart/:id" \
+    "$(status DELETE "$API_BASE_URL/videos/upload/multipart/$MULTIPART_ID" "$OWNER_TOKEN")"
+# 视频还没有转码，权限检查通过后返回 404 (没有内容密钥)
+expect_status "GET /videos/:id/key (未转码)" 404 \
+    "$(status GET "$API_BASE_URL/videos/$SINGLE_ID/key" "$OWNER_TOKEN")"
+# 默认配置 (watermark.opt_out_roles: ["admin"]) 下普通用户不能关闭水印，但可以恢复
+expect_status "PUT /me/watermark (普通用户关闭水印)" 403 \
+    "$(status PUT "$API_BASE_URL/me/watermark" "$OWNER_TOKEN" -H "Content-Type: application/json" -d '{"opt_out": true}')"
+expect_status "PUT /me/watermark (普通用户恢复水印)" 200 \
+    "$(status PUT "$API_BASE_URL/me/watermark" "$OWNER_TOKEN" -H "Content-Type: application/json" -d '{"opt_out": false}')"
+
+# ==============================================================================
+#                    3. 公开视频: 可评论，但不能管理 (403)
+# ==============================================================================
+
+if [ -n "$ONLINE_VIDEO_ID" ]; then
+    print_header "3. 其他用户 -> 公开视频 $ONLINE_VIDEO_ID"
+
+    expect_status "POST /videos/upload/complete" 403 \
+        "$(status POST "$API_BASE_URL/videos/upload/complete" "$OTHER_TOKEN" -H "Content-Type: application/json" -d "{\"video_id\": $ONLINE_VIDEO_ID}")"
+    expect_status "DELETE /videos/upload/multipart/:id" 403 \
+        "$(status DELETE "$API_BASE_URL/videos/upload/multipart/$ONLINE_VIDEO_ID" "$OTHER_TOKEN")"
+    expect_status "DELETE /videos/:id" 403 \
+        "$(status DELETE "$API_BASE_URL/videos/$ONLINE_VIDEO_ID" "$OTHER_TOKEN")"
+    # 原始文件元数据只返回给所有者和管理员
+    if curl -s "$API_BASE_URL/videos/$ONLINE_VIDEO_ID" -H "Authorization: Bearer $OTHER_TOKEN" | jq -e 'has("metadata")' > /dev/null; then
+        echo "FAIL> GET /videos/:id: metadata exposed to another user"
+        FAILED=$((FAILED + 1))
+    else
+        echo "PASS> GET /videos/:id (其他用户看不到 metadata)"
+    fi
+    expect_status "GET /videos/:id (无效令牌)" 401 \
+        "$(status GET "$API_BASE_URL/videos/$ONLINE_VIDEO_ID" "invalid-token")"
+
+    COMMENT_ID=$(curl -s -X POST "$API_BASE_URL/videos/$ONLINE_VIDEO_ID/comments" \
+        -H "Authorization: Bearer $OTHER_TOKEN" -H "Content-Type: application/json" \
+        -d '{"content": "authz comment"}' | jq -r '.id')
+    if [ -n "$COMMENT_ID" ] && [ "$COMMENT_ID" != "null" ]; then
+        echo "PASS> POST /videos/:id/comments (comment $COMMENT_ID)"
+    else
+        echo "FAIL> POST /videos/:id/comments on online video"
+        FAILED=$((FAILED + 1))
+    fi
+    expect_status "DELETE /videos/:id/comments/:comment_id (非作者)" 403 \
+        "$(status DELETE "$API_BASE_URL/videos/$ONLINE_VIDEO_ID/comments/$COMMENT_ID" "$OWNER_TOKEN")"
+    expect_status "DELETE /videos/:id/comments/:comment_id (不存在)" 404 \
+        "$(status DELETE "$API_BASE_URL/videos/$ONLINE_VIDEO_ID/comments/999999999" "$OTHER_TOKEN")"
+    expect_status "DELETE /videos/:id/comments/:comment_id (作者)" 200 \
+        "$(status DELETE "$API_BASE_URL/videos/$ONLINE_VIDEO_ID/comments/$COMMENT_ID" "$OTHER_TOKEN")"
+else
+    print_header "3. 跳过公开视频测试 (未设置 ONLINE_VIDEO_ID)"
+fi
+
+# ==============================================================================
+#                    4. 管理员 / 审核员
+# ==============================================================================
+
+if [ -n "$ADMIN_EMAIL" ]; then
+    print_header "4.1 管理员 -> 别人未公开的视频"
+    ADMIN_TOKEN=$(login "$ADMIN_EMAIL" "$ADMIN_PASSWORD")
+    expect_status "POST /videos/upload/complete (文件未上传)" 409 \
+        "$(status POST "$API_BASE_URL/videos/upload/complete" "$ADMIN_TOKEN" -H "Content-Type: application/json" -d "{\"video_id\": $SINGLE_ID}")"
+    expect_allowed "POST /videos/:id/comments" \
+        "$(status POST "$API_BASE_URL/videos/$SINGLE_ID/comments" "$ADMIN_TOKEN" -H "Content-Type: application/json" -d '{"content": "admin note"}')"
+    expect_status "PUT /me/watermark (管理员关闭水印)" 200 \
+        "$(status PUT "$API_BASE_URL/me/watermark" "$ADMIN_TOKEN" -H "Content-Type: application/json" -d '{"opt_out": true}')"
+    expect_status "PUT /me/watermark (管理员恢复水印)" 200 \
+        "$(status PUT "$API_BASE_URL/me/watermark" "$ADMIN_TOKEN" -H "Content-Type: application/json" -d '{"opt_out": false}')"
+fi
+
+if [ -n "$AUDITOR_EMAIL" ]; then
+    print_header "4.2 审核员 -> 别人未公开的视频 (可见但不能管理)"
+    AUDITOR_TOKEN=$(login "$AUDITOR_EMAIL" "$AUDITOR_PASSWORD")
+    expect_status "POST /videos/upload/complete" 403 \
+        "$(status POST "$API_BASE_URL/videos/upload/complete" "$AUDITOR_TOKEN" -H "Content-Type: application/json" -d "{\"video_id\": $SINGLE_ID}")"
+    OWNER_COMMENT_ID=$(curl -s -X POST "$API_BASE_URL/videos/$SINGLE_ID/comments" \
+        -H "Authorization: Bearer $OWNER_TOKEN" -H "Content-Type: application/json" \
+        -d '{"content": "to be moderated"}' | jq -r '.id')
+    expect_status "DELETE /videos/:id/comments/:comment_id" 200 \
+        "$(status DELETE "$API_BASE_URL/videos/$SINGLE_ID/comments/$OWNER_COMMENT_ID" "$AUDITOR_TOKEN")"
+fi
+
+# 最后由所有者删除自己的视频
+print_header "5. 所有者删除自己的视频"
+expect_status "DELETE /videos/:id" 200 "$(status DELETE "$API_BASE_URL/videos/$SINGLE_ID" "$OWNER_TOKEN")"
+expect_status "DELETE /videos/:id (已删除)" 404 "$(status DELETE "$API_BASE_URL/videos/$SINGLE_ID" "$OWNER_TOKEN")"
+
+# ==============================================================================
+#                              结果
+# ==============================================================================
+
+print_header "测试结束"
+if [ "$FAILED" -ne 0 ]; then
+    echo "$FAILED 项检查失败"
+    exit 1
+fi
+echo "全部通过"
@@ -41,11 +41,12 @@ func main() {
 	config.Init()
 	log.Println("Configuration loaded")
 
-	// 2. 初始化数据库、MinIO 和 RabbitMQ
+	// 2. 初始化数据库、MinIO、RabbitMQ 和 Redis
 	dal.InitMySQL(&config.AppConfig)
 	dal.InitMinIO(&config.AppConfig)
 	dal.InitRabbitMQ(&config.AppConfig)
-	log.Println("Database, MinIO and RabbitMQ initialized")
+	dal.InitRedis(&config.AppConfig)
+	log.Println("Database, MinIO, RabbitMQ and Redis initialized")
 
 	// 3. 设置 Gin 引擎
 	r := gin.Default()
@@ -66,11 +67,20 @@ func main() {
 
 		// 公开的视频查询路由
 		apiV1.GET("/videos", handler.ListVideos)
-		apiV1.GET("/videos/:id", handler.GetVideoDetails)
+		// 带令牌访问时，所有者和管理员额外看到原始文件元数据
+		apiV1.GET("/videos/:id", middleware.OptionalJWTAuthMiddleware(), handler.GetVideoDetails)
+		// 加密视频的内容密钥: 播放令牌 (?token=) 或带观看权限的令牌
+		apiV1.GET("/videos/:id/key", middleware.OptionalJWTAuthMiddleware(), handler.GetVideoKey)
 		// 获取评论的路由 (GET方法)
 		apiV1.GET("/videos/:id/comments", handler.ListComments)
 
+		// MinIO 存储桶事件通知 (使用 minio.webhook_token 鉴权，不走 JWT)
+		apiV1.POST("/hooks/minio", handler.MinioWebhook)
+
 		// --- 需要认证的路由 ---
+		// 修改视频/评论的接口在 handler 中统一通过 service.AuthorizeVideo / AuthorizeComment 做权限检查:
+		// 所有者或管理员可以管理视频；评论作者、管理员或审核员可以删除评论；
+		// 看不到的视频返回 404，看得到但无权操作返回 403
 		authed := apiV1.Group("/")
 		authed.Use(middleware.JWTAuthMiddleware())
 		{
@@ -84,17 +94,68 @@ func main() {
 					"role":    role,
 				})
 			})
+
+			// 当前用户的配额和使用量 (上传器 UI 显示剩余容量)
+			authed.GET("/me/quota", handler.GetMyQuota)
+			// 当前用户的水印设置，允许的角色可以为自己的视频关闭水印
+			authed.GET("/me/watermark", handler.GetMyWatermark)
+			authed.PUT("/me/watermark", handler.SetMyWatermarkOptOut)
 			
 			// 视频上传路由
 			videoRoutes := authed.Group("/videos")
 			{
 				videoRoutes.POST("/upload/initiate", handler.InitiateUpload)
 				videoRoutes.POST("/upload/complete", handler.CompleteUpload)
+
+				// 分片上传 (大文件 / 断点续传)，完成时同样调用 /upload/complete
+				videoRoutes.POST("/upload/multipart/initiate", handler.InitiateMultipartUpload)
+				videoRoutes.GET("/upload/multipart/:id/parts", handler.ListUploadedParts)
+				videoRoutes.GET("/upload/multipart/:id/parts/:part", handler.PresignUploadPart)
+				videoRoutes.DELETE("/upload/multipart/:id", handler.AbortMultipartUpload)
+
+				// 从内部 HTTP 源站导入，由 Worker 下载
+				videoRoutes.POST("/import", handler.ImportVideo)
+				videoRoutes.GET("/:id/import", handler.GetImportStatus)
+
+				// 转码进度 (JSON 轮询或 SSE 推送)
+				videoRoutes.GET("/:id/progress", handler.GetTranscodeProgress)
+
+				// 封面: 查看候选封面、选择候选或上传自定义图片
+				videoRoutes.GET("/:id/covers", handler.ListVideoCovers)
+				videoRoutes.POST("/:id/cover/upload", handler.PresignCoverUpload)
+				videoRoutes.PUT("/:id/cover", handler.SetVideoCover)
+
+				// 字幕: 按语言上传 (SRT / WebVTT / ASS，统一转换为 WebVTT) 和删除
+				videoRoutes.PUT("/:id/subtitles/:language", handler.UploadSubtitle)
+				videoRoutes.DELETE("/:id/subtitles/:language", handler.DeleteSubtitle)
+
+				// tus 1.0 断点续传 (移动端 App / 桌面上传器)
+				tusRoutes := videoRoutes.Group("/upload/tus")
+				tusRoutes.Use(middleware.TusResumableHeader())
+				{
+					tusRoutes.OPTIONS("", handler.TusOptions)
+					tusRoutes.POST("", handler.TusCreate)
+					tusRoutes.HEAD("/:id", handler.TusHead)
+					tusRoutes.PATCH("/:id", handler.TusPatch)
+					tusRoutes.DELETE("/:id", handler.TusDelete)
+				}
 			}
 
 			// 创建评论的路由 (POST方法)
 			// <--- 关键在这里！这条路由必须在 authed 分组内！
 			authed.POST("/videos/:id/comments", handler.CreateComment)
+			authed.DELETE("/videos/:id/comments/:comment_id", handler.DeleteComment)
+
+			// 删除视频 (共享的转码产物按引用计数清理)
+			authed.DELETE("/videos/:id", handler.DeleteVideo)
+
+			// 管理员接口: 查看和重新投递重试耗尽的转码任务
+			adminRoutes := authed.Group("/admin")
+			adminRoutes.Use(middleware.RequireRole("admin"))
+			{
+				adminRoutes.GET("/dlq/transcode", handler.ListTranscodeDeadLetters)
+				adminRoutes.POST("/dlq/transcode/replay", handler.ReplayTranscodeDeadLetters)
+			}
 		}
 	}
 
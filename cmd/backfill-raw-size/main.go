@@ -0,0 +1,78 @@
+// cmd/backfill-raw-size/main.go
+//
+// 一次性迁移: 添加 videos.raw_size 列，并为已经完成上传的视频从 MinIO 读取原始文件大小。
+// 配额统计只对 raw_size 求和，不回填时旧视频的原始文件不计入存储用量。可以重复执行，
+// 已有 raw_size 的视频会被跳过。需要在 cmd/migrate-keys 之后运行。
+//
+//	go run ./cmd/backfill-raw-size -dry-run   # 只打印将要执行的操作
+//	go run ./cmd/backfill-raw-size
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only print what would be done")
+	flag.Parse()
+
+	config.Init()
+	dal.InitMySQL(&config.AppConfig)
+	dal.InitMinIO(&config.AppConfig)
+
+	// 1. 补上 raw_size 列 (新部署的 sql/video.sql 已包含)
+	query := dal.DB.Where("raw_size = ?", 0)
+	if !dal.DB.Migrator().HasColumn(&model.Video{}, "RawSize") {
+		log.Println("Adding column videos.raw_size")
+		if *dryRun {
+			query = dal.DB
+		} else if err := dal.DB.Migrator().AddColumn(&model.Video{}, "RawSize"); err != nil {
+			log.Fatalf("Failed to add column raw_size: %v", err)
+		}
+	}
+
+	// 2. 上传中的视频在完成上传时记录大小；没有 object_key 的视频需要先运行 cmd/migrate-keys
+	var videos []model.Video
+	if err := query.Where("status <> ? AND object_key <> ?", "uploading", "").Find(&videos).Error; err != nil {
+		log.Fatalf("Failed to query videos: %v", err)
+	}
+	log.Printf("%d videos to backfill", len(videos))
+
+	ctx := context.Background()
+	bucketName := config.AppConfig.MinIO.BucketName
+	var updated, missing, failed int
+
+	for i := range videos {
+		video := &videos[i]
+		info, err := dal.MinioClient.StatObject(ctx, bucketName, video.RawObjectKey(), minio.StatObjectOptions{})
+		if err != nil {
+			if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+				// 原始文件已被清理，不占用存储
+				missing++
+				continue
+			}
+			log.Printf("video %d: failed to stat %s: %v", video.ID, video.RawObjectKey(), err)
+			failed++
+			continue
+		}
+
+		log.Printf("video %d: raw_size = %d", video.ID, info.Size)
+		if !*dryRun {
+			if err := dal.DB.Model(video).Update("raw_size", info.Size).Error; err != nil {
+				log.Printf("video %d: failed to update row: %v", video.ID, err)
+				failed++
+				continue
+			}
+		}
+		updated++
+	}
+
+	log.Printf("Done: updated=%d missing=%d failed=%d (dry run: %t)", updated, missing, failed, *dryRun)
+}
@@ -0,0 +1,141 @@
+// cmd/migrate-keys/main.go
+//
+// 一次性迁移: 把旧版本按 raw/<id>/<客户端文件名> 保存的原始文件改为服务端生成的对象路径，
+// 并清理 videos.original_file_name。可以重复执行，已迁移的视频会被跳过。
+//
+//	go run ./cmd/migrate-keys -dry-run   # 只打印将要执行的操作
+//	go run ./cmd/migrate-keys
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/minio/minio-go/v7"
+)
+
+// legacyRawObjectKey 是旧版本 RawObjectKey 的实现
+func legacyRawObjectKey(video *model.Video) string {
+	return path.Join("raw", video.IDString(), video.OriginalFileName)
+}
+
+// legacyKeySafe 判断旧路径是否仍在 raw/<id>/ 下且不含控制字符，可以继续沿用
+func legacyKeySafe(video *model.Video, key string) bool {
+	return strings.HasPrefix(key, "raw/"+video.IDString()+"/") &&
+		strings.Count(key, "/") == 2 &&
+		len(key) <= 255 &&
+		service.SanitizeDisplayName(path.Base(key)) == path.Base(key)
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only print what would be done")
+	flag.Parse()
+
+	config.Init()
+	dal.InitMySQL(&config.AppConfig)
+	dal.InitMinIO(&config.AppConfig)
+
+	// 1. 补上 object_key 列 (新部署的 sql/video.sql 已包含)
+	query := dal.DB.Where("object_key = ?", "")
+	if !dal.DB.Migrator().HasColumn(&model.Video{}, "ObjectKey") {
+		log.Println("Adding column videos.object_key")
+		if *dryRun {
+			query = dal.DB
+		} else if err := dal.DB.Migrator().AddColumn(&model.Video{}, "ObjectKey"); err != nil {
+			log.Fatalf("Failed to add column object_key: %v", err)
+		}
+	}
+
+	var videos []model.Video
+	if err := query.Find(&videos).Error; err != nil {
+		log.Fatalf("Failed to query videos: %v", err)
+	}
+	log.Printf("%d videos to migrate", len(videos))
+
+	ctx := context.Background()
+	bucketName := config.AppConfig.MinIO.BucketName
+	var moved, kept, failed int
+
+	for i := range videos {
+		video := &videos[i]
+		oldKey := legacyRawObjectKey(video)
+		displayName := service.SanitizeDisplayName(video.OriginalFileName)
+
+		// 2. 上传中的视频: 客户端手里的预签名 URL / 分片会话都绑定了旧路径，只能沿用；旧路径不安全时放弃这次上传
+		if video.Status == "uploading" {
+			if legacyKeySafe(video, oldKey) {
+				log.Printf("video %d: uploading, keeping %s", video.ID, oldKey)
+				kept++
+				if !*dryRun {
+					dal.DB.Model(video).Updates(map[string]interface{}{"object_key": oldKey, "original_file_name": displayName})
+				}
+				continue
+			}
+			log.Printf("video %d: uploading with unsafe key %q, marking failed", video.ID, oldKey)
+			failed++
+			if !*dryRun {
+				var session model.UploadSession
+				if err := dal.DB.Where("video_id = ? AND status = ?", video.ID, "active").First(&session).Error; err == nil {
+					dal.MinioCore.AbortMultipartUpload(ctx, bucketName, session.ObjectKey, session.UploadID)
+					dal.DB.Model(&session).Update("status", "aborted")
+				}
+				dal.DB.Model(video).Updates(map[string]interface{}{"status": "failed", "original_file_name": displayName})
+			}
+			continue
+		}
+
+		// 3. 其他视频: 把原始文件复制到新路径后删除旧对象
+		video.OriginalFileName = displayName
+		newKey := service.NewRawObjectKey(video)
+		objectExists := false
+		// 旧路径经过 path.Join 清理后可能逃出 raw/<id>/ (例如文件名为 ../../processed/...)，这种对象不属于该视频，不能移动
+		if strings.HasPrefix(oldKey, "raw/"+video.IDString()+"/") {
+			_, err := dal.MinioClient.StatObject(ctx, bucketName, oldKey, minio.StatObjectOptions{})
+			if err != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+				log.Printf("video %d: failed to stat %s: %v", video.ID, oldKey, err)
+				continue
+			}
+			objectExists = err == nil
+		} else {
+			log.Printf("video %d: legacy key %q escapes raw/%d/, not moving it", video.ID, oldKey, video.ID)
+		}
+
+		log.Printf("video %d: %s -> %s (object exists: %t)", video.ID, oldKey, newKey, objectExists)
+		if *dryRun {
+			moved++
+			continue
+		}
+		if objectExists {
+			// ComposeObject 支持超过 5GB 的服务端复制
+			if _, err := dal.MinioClient.ComposeObject(ctx,
+				minio.CopyDestOptions{Bucket: bucketName, Object: newKey},
+				minio.CopySrcOptions{Bucket: bucketName, Object: oldKey},
+			); err != nil {
+				log.Printf("video %d: failed to copy object: %v", video.ID, err)
+				continue
+			}
+		}
+		if err := dal.DB.Model(video).Updates(map[string]interface{}{
+			"object_key":         newKey,
+			"original_file_name": displayName,
+		}).Error; err != nil {
+			log.Printf("video %d: failed to update row: %v", video.ID, err)
+			continue
+		}
+		if objectExists {
+			if err := dal.MinioClient.RemoveObject(ctx, bucketName, oldKey, minio.RemoveObjectOptions{}); err != nil {
+				log.Printf("video %d: failed to remove old object %s: %v", video.ID, oldKey, err)
+			}
+		}
+		moved++
+	}
+
+	log.Printf("Done: moved=%d kept=%d failed=%d skipped=%d (dry run: %t)", moved, kept, failed, len(videos)-moved-kept-failed, *dryRun)
+}
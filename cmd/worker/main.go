@@ -2,13 +2,19 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/cjh/video-platform-go/internal/config"
 	"github.com/cjh/video-platform-go/internal/dal"
-	"github.com/cjh/video-platform-go/internal/service"
 	"github.com/cjh/video-platform-go/internal/worker"
+	"github.com/rabbitmq/amqp091-go"
 )
 
 func main() {
@@ -16,54 +22,91 @@ func main() {
 	config.Init()
 	log.Println("Worker: Configuration loaded")
 
-	// 2. 初始化所有连接 (数据库, MinIO, RabbitMQ)
+	// 2. 初始化所有连接 (数据库, MinIO, RabbitMQ, Redis)
 	dal.InitMySQL(&config.AppConfig)
 	dal.InitMinIO(&config.AppConfig)
 	dal.InitRabbitMQ(&config.AppConfig) // Worker也需要连接MQ来消费
-	log.Println("Worker: Database, MinIO and RabbitMQ initialized")
+	dal.InitRedis(&config.AppConfig)    // 发布转码进度
+	log.Println("Worker: Database, MinIO, RabbitMQ and Redis initialized")
 
-	// 3. 开始消费消息
-	qName := config.AppConfig.RabbitMQ.TranscodeQueue
-	msgs, err := dal.MQChan.Consume(
-		qName, // queue
-		"",    // consumer
-		false, // auto-ack (!!!) 我们要手动确认
-		false, // exclusive
-		false, // no-local
-		false, // no-wait
-		nil,   // args
-	)
-	if err != nil {
-		log.Fatalf("Failed to register a consumer: %v", err)
+	// 收到 SIGINT / SIGTERM 后停止消费，等待进行中的任务
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// jobCtx 传给正在执行的任务，关闭超时后才取消
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	// 3. 每个队列由 concurrency 个 goroutine 并发处理，prefetch 与之相同，多余的消息留在队列里给其他 Worker
+	concurrency := config.AppConfig.Worker.Concurrency
+	if err := dal.MQChan.Qos(concurrency, 0, false); err != nil {
+		log.Fatalf("Failed to set QoS: %v", err)
 	}
 
-	// 4. 使用一个 "forever" channel 来阻塞主 goroutine
-	var forever chan struct{}
+	var wg sync.WaitGroup
+	var consumerTags []string
+	consume := func(queue string, handle func(context.Context, amqp091.Delivery)) {
+		tag := fmt.Sprintf("worker-%d-%s", os.Getpid(), queue)
+		msgs, err := dal.MQChan.Consume(
+			queue, // queue
+			tag,   // consumer
+			false, // auto-ack (!!!) 我们要手动确认
+			false, // exclusive
+			false, // no-local
+			false, // no-wait
+			nil,   // args
+		)
+		if err != nil {
+			log.Fatalf("Failed to register a consumer for %s: %v", queue, err)
+		}
+		consumerTags = append(consumerTags, tag)
+		worker.ConsumeConcurrently(ctx, jobCtx, &wg, msgs, concurrency, handle)
+		log.Printf("Worker: consuming %s with concurrency %d", queue, concurrency)
+	}
 
-	go func() {
-		for d := range msgs {
-			log.Printf("Received a message: %s", d.Body)
+	// 3.1 转码任务，失败时按配置延迟重试，重试耗尽后转入死信队列
+	consume(config.AppConfig.RabbitMQ.TranscodeQueue, worker.HandleTranscodeDelivery)
 
-			var task service.TranscodeTaskPayload
-			if err := json.Unmarshal(d.Body, &task); err != nil {
-				log.Printf("Error unmarshalling message: %s", err)
-				d.Nack(false, false) // 消息格式错误，直接丢弃
-				continue
-			}
+	// 3.2 远程 URL 导入任务
+	if importQueue := config.AppConfig.RabbitMQ.ImportQueue; importQueue != "" {
+		consume(importQueue, worker.HandleImportDelivery)
+	}
 
-			// 调用真正的处理函数
-			err := worker.HandleTranscode(task.VideoID)
-			if err != nil {
-				log.Printf("Failed to handle transcode for video %d: %v", task.VideoID, err)
-				// 这里可以加入重试逻辑，但现在我们先简单地 Nack
-				d.Nack(false, false) // 处理失败，也可以选择 requeue
-			} else {
-				log.Printf("Successfully transcoded video %d", task.VideoID)
-				d.Ack(false) // !!! 非常重要：处理成功后，手动确认消息
-			}
+	// 3.3 可选：消费 MinIO 的上传完成事件，客户端忘记调用 /upload/complete 时自动提交转码
+	if eventsQueue := config.AppConfig.RabbitMQ.UploadEventsQueue; eventsQueue != "" {
+		if err := dal.DeclareUploadEventsQueue(&config.AppConfig); err != nil {
+			log.Fatalf("Failed to declare upload events queue: %v", err)
 		}
-	}()
+		consume(eventsQueue, worker.HandleUploadEventDelivery)
+	}
+
+	// 4. 定期清理过期上传、孤立文件和卡住的转码
+	go worker.RunReaper(ctx)
 
 	log.Printf(" [*] Waiting for messages. To exit press CTRL+C")
-	<-forever // 阻塞主线程，让 worker 一直运行
-}
\ No newline at end of file
+	<-ctx.Done()
+
+	// 5. 优雅退出: 停止接收新消息，等待进行中的任务，超时后中止并把消息退回队列
+	log.Println("Worker: shutting down, waiting for in-flight jobs")
+	for _, tag := range consumerTags {
+		if err := dal.MQChan.Cancel(tag, false); err != nil {
+			log.Printf("Failed to cancel consumer %s: %v", tag, err)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	timeout := time.Duration(config.AppConfig.Worker.ShutdownTimeoutSeconds) * time.Second
+	select {
+	case <-done:
+		log.Println("Worker: all jobs finished")
+	case <-time.After(timeout):
+		log.Printf("Worker: shutdown timeout (%s) reached, aborting in-flight jobs", timeout)
+		cancelJobs()
+		<-done
+	}
+	// 关闭连接时，没有确认的消息由 RabbitMQ 重新投递
+	dal.MQConn.Close()
+}
@@ -24,9 +24,71 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/users/login": {
+        "/admin/dlq/transcode": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回重试耗尽的转码任务 (视频 ID、尝试次数、最后一次错误)，不会把任务移出队列。仅管理员可用",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "管理"
+                ],
+                "summary": "查看转码死信队列",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "最多返回的任务数，默认 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DeadLetterListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dlq/transcode/replay": {
             "post": {
-                "description": "根据邮箱和密码进行登录，成功后返回 JWT Token",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "把死信队列中的任务重新发布到转码队列 (重试次数清零)，视频状态从 dead_lettered 恢复为 transcoding。\n已删除或状态已变化的视频对应的任务会被丢弃；原始文件已不存在的任务无法重新转码，列在 rejected 中 (附错误原因) 并移出死信队列，视频标记为 failed。仅管理员可用",
                 "consumes": [
                     "application/json"
                 ],
@@ -34,17 +96,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "管理"
                 ],
-                "summary": "用户登录",
+                "summary": "重新投递转码死信任务",
                 "parameters": [
                     {
-                        "description": "登录请求体",
+                        "description": "要重新投递的视频，为空时全部投递",
                         "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginRequest"
+                            "$ref": "#/definitions/handler.ReplayDeadLettersRequest"
                         }
                     }
                 ],
@@ -52,7 +113,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.LoginResponse"
+                            "$ref": "#/definitions/service.ReplayResult"
                         }
                     },
                     "400": {
@@ -66,13 +127,31 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/users/register": {
+        "/hooks/minio": {
             "post": {
-                "description": "根据用户提供的昵称、邮箱和密码进行注册",
+                "description": "接收 MinIO 的 s3:ObjectCreated:* 事件，原始文件上传到 raw/ 后自动完成上传并提交转码，客户端无需再调用 /videos/upload/complete",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,26 +159,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "内部"
                 ],
-                "summary": "用户注册",
+                "summary": "MinIO 存储桶事件通知",
                 "parameters": [
                     {
-                        "description": "注册请求体",
+                        "type": "string",
+                        "description": "MinIO webhook 令牌 (Bearer {token} 或 {token})",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "MinIO 事件",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.RegisterRequest"
+                            "$ref": "#/definitions/service.MinioEvent"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/handler.RegisterResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -107,8 +190,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -116,36 +205,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos": {
+        "/me/quota": {
             "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回当前用户的配额 (0 表示不限制)、已用存储和今日上传数量，以及剩余额度 (-1 表示不限制)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "视频"
-                ],
-                "summary": "获取视频列表",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "每页数量",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 0,
-                        "description": "偏移量",
-                        "name": "offset",
-                        "in": "query"
-                    }
+                    "用户"
                 ],
+                "summary": "获取我的配额",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.ListVideosResponse"
+                            "$ref": "#/definitions/handler.QuotaResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -157,44 +242,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/upload/complete": {
-            "post": {
+        "/me/watermark": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "通知后端开始转码",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "返回平台是否启用水印、当前角色能否关闭水印，以及之后转码的视频实际叠加的水印 (图片、位置、边距、不透明度、缩放比例，不叠加时为 null)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "视频"
-                ],
-                "summary": "完成视频上传",
-                "parameters": [
-                    {
-                        "description": "视频 ID",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handler.CompleteUploadRequest"
-                        }
-                    }
+                    "用户"
                 ],
+                "summary": "获取我的水印设置",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.MessageResponse"
+                            "$ref": "#/definitions/service.WatermarkSettings"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -206,16 +277,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/videos/upload/initiate": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "生成预签名上传 URL",
+                "description": "opt_out 为 true 时，之后转码的视频不再叠加平台水印，仅 watermark.opt_out_roles 中的角色可以关闭；\n已经转码完成的视频不受影响",
                 "consumes": [
                     "application/json"
                 ],
@@ -223,17 +292,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "视频"
+                    "用户"
                 ],
-                "summary": "初始化视频上传",
+                "summary": "关闭或恢复水印",
                 "parameters": [
                     {
-                        "description": "上传元数据",
+                        "description": "是否关闭水印",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.InitiateUploadRequest"
+                            "$ref": "#/definitions/handler.SetWatermarkOptOutRequest"
                         }
                     }
                 ],
@@ -241,7 +310,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.InitiateUploadResponse"
+                            "$ref": "#/definitions/service.WatermarkSettings"
                         }
                     },
                     "400": {
@@ -256,6 +325,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -265,29 +340,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}": {
-            "get": {
+        "/users/login": {
+            "post": {
+                "description": "根据邮箱和密码进行登录，成功后返回 JWT Token",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "视频"
+                    "用户"
                 ],
-                "summary": "获取视频详情",
+                "summary": "用户登录",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "视频 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "登录请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.VideoDetailsResponse"
+                            "$ref": "#/definitions/handler.LoginResponse"
                         }
                     },
                     "400": {
@@ -296,8 +377,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -305,33 +386,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/videos/{id}/comments": {
-            "get": {
-                "description": "根据视频 ID 获取评论 / 弹幕列表",
+        "/users/register": {
+            "post": {
+                "description": "根据用户提供的昵称、邮箱和密码进行注册",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "评论"
+                    "用户"
                 ],
-                "summary": "获取评论列表",
+                "summary": "用户注册",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "视频 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "注册请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handler.CommentInfo"
-                            }
+                            "$ref": "#/definitions/handler.RegisterResponse"
                         }
                     },
                     "400": {
@@ -340,21 +423,64 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+            }
+        },
+        "/videos": {
+            "get": {
+                "produces": [
+                    "application/json"
                 ],
-                "description": "需要登录。根据视频 ID 创建评论或弹幕",
+                "tags": [
+                    "视频"
+                ],
+                "summary": "获取视频列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "偏移量",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ListVideosResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建视频记录并提交导入任务，由 Worker 从源站 (需在 import.allowed_hosts 白名单中) 下载到 MinIO，下载完成后自动校验并转码",
                 "consumes": [
                     "application/json"
                 ],
@@ -362,32 +488,193 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "评论"
+                    "视频"
                 ],
-                "summary": "创建评论 / 弹幕",
+                "summary": "从远程 URL 导入视频",
+                "parameters": [
+                    {
+                        "description": "导入源和视频元数据",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportVideoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportVideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/complete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "通知后端开始转码；如果是分片上传，会先合并所有已上传的分片。\n提交前会校验对象是否存在、大小、Content-Type、可选的 SHA-256 以及 ffprobe 能否解析；\nffprobe 超时或无法读取对象时返回 503 (code 为 probe_unavailable)，视频保持 uploading，可以稍后重试",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "完成视频上传",
                 "parameters": [
                     {
-                        "type": "integer",
                         "description": "视频 ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CompleteUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/initiate": {
+            "post": {
+                "security": [
                     {
-                        "description": "评论内容",
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "生成预签名上传 URL",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "初始化视频上传",
+                "parameters": [
+                    {
+                        "description": "上传元数据",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.CreateCommentRequest"
+                            "$ref": "#/definitions/handler.InitiateUploadRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.CommentInfo"
+                            "$ref": "#/definitions/handler.InitiateUploadResponse"
                         }
                     },
                     "400": {
@@ -402,6 +689,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -410,108 +709,2254 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.CommentInfo": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "example": "Great video!"
+        },
+        "/videos/upload/multipart/initiate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建视频记录并开启 MinIO 分片上传会话，适用于大文件和断点续传",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "初始化分片上传",
+                "parameters": [
+                    {
+                        "description": "上传元数据",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitiateMultipartUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitiateMultipartUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/multipart/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "放弃本次上传，清理已上传的分片并将视频标记为失败",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "取消分片上传",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/multipart/{id}/parts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "断点续传时用于查询哪些分片已经上传成功",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "列出已上传的分片",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ListPartsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/multipart/{id}/parts/{part}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "为指定序号的分片生成预签名 PUT URL，客户端上传后需保留响应头中的 ETag",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "获取分片上传地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片序号 (1-10000)",
+                        "name": "part",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PresignPartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/upload/tus": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "tus creation 扩展。Upload-Metadata 中必须包含 filename，可选 title 和 description",
+                "tags": [
+                    "视频"
+                ],
+                "summary": "创建 tus 上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "文件总大小 (字节)",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "base64 编码的元数据",
+                        "name": "Upload-Metadata",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "options": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回服务端支持的 tus 版本和扩展",
+                "tags": [
+                    "视频"
+                ],
+                "summary": "tus 能力发现",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
+        "/videos/upload/tus/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "tus termination 扩展，清理已上传的数据并将视频标记为失败",
+                "tags": [
+                    "视频"
+                ],
+                "summary": "终止 tus 上传",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回服务端已接收的字节数 (Upload-Offset)",
+                "tags": [
+                    "视频"
+                ],
+                "summary": "查询 tus 上传进度",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "从 Upload-Offset 处追加数据，写满 Upload-Length 后自动合并并提交转码",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "上传 tus 数据块",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "1.0.0",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "本次数据的起始偏移",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}": {
+            "get": {
+                "description": "sources 中每个清晰度有两条播放源: format 为 HLS (master.m3u8) 和 DASH (manifest.mpd)，共享同一组 CMAF (fMP4) 分片；quality 为 auto 的 HLS 播放源是包含所有清晰度的自适应码率主播放列表，同时作为 playback_url 返回。thumbnail_vtt_url 为进度条预览图的 WebVTT 轨道 (cue 指向雪碧图中的区域 #xywh=x,y,w,h)，未生成时为空。subtitles 列出各语言的字幕轨道 (url 为 WebVTT 文件，playlist_url 为 HLS 字幕播放列表)，有字幕且带 SUBTITLES 字幕组的主播放列表已生成时 playback_url 指向它。media_type 为 audio 时是纯音频 (播放源只有 AAC 音频，没有预览图)，waveform_url 为波形数据 (audiowaveform JSON 格式，data 为每个点的最小值和最大值)。loudness 为原始音频的 EBU R128 响度测量值，播放源已标准化到 target_lufs，replay_gain_db 为相对 ReplayGain 参考响度 (-18 LUFS) 的增益，未做标准化时省略。启用加密后转码的视频只有 HLS 播放源 (AES-128 加密的 TS 分片)，没有 DASH；此时返回 playback_token (短期有效)，播放器请求播放列表中的密钥地址 (/videos/{id}/key) 时以 ?token= 附加。所有者和管理员带令牌访问时额外返回 metadata (原始文件的容器、各个流的编码、分辨率、帧率、码率等)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "获取视频详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "可选，Bearer {token}；令牌无效或已过期时按匿名访问",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.VideoDetailsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除视频及其评论和播放源，仅所有者和管理员可操作。与其他视频共享的转码产物在最后一个引用被删除时才会清理",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "删除视频",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/comments": {
+            "get": {
+                "description": "根据视频 ID 获取评论 / 弹幕列表",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "评论"
+                ],
+                "summary": "获取评论列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handler.CommentInfo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要登录。根据视频 ID 创建评论或弹幕",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "评论"
+                ],
+                "summary": "创建评论 / 弹幕",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "评论内容",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CommentInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/comments/{comment_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "需要登录。评论作者、管理员或审核员可以删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "评论"
+                ],
+                "summary": "删除评论 / 弹幕",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "评论 ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/cover": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "candidate_id 选择一张候选封面；upload_key 使用上传的自定义图片，服务端校验格式和尺寸，\n缩放到 cover.max_width x cover.max_height 以内并重新编码为 JPEG。两者只能指定一个。仅所有者和管理员",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "设置封面",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "候选封面或上传的图片",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetCoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SetCoverResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/cover/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "生成预签名 PUT URL，客户端上传图片 (JPEG / PNG / WebP，大小上限见 cover.max_upload_size_mb) 后，\n用返回的 upload_key 调用 PUT /videos/{id}/cover。转码完成前不能修改封面。仅所有者和管理员",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "获取自定义封面上传地址",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CoverUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/covers": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回当前封面和转码时截取的候选封面 (已跳过黑帧)，URL 为带签名的临时地址。仅所有者和管理员",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "查看候选封面",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CoverCandidatesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/import": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回远程 URL 导入任务的状态和已下载字节数，仅视频所有者和管理员可查看",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "查询导入进度",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/key": {
+            "get": {
+                "description": "加密视频的 HLS 播放列表 (EXT-X-KEY) 指向该接口，返回 16 字节的 AES-128 密钥。调用者需要带视频详情返回的 playback_token (?token=，短期有效，只能用于该视频)，或者带有观看权限的 Bearer 令牌 (公开视频、所有者、管理员或审核员)。浏览器播放器通常无法为密钥请求附加 Authorization 头，应使用 playback_token，例如在 hls.js 的 xhrSetup 中为密钥地址追加 token 参数",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "获取加密视频的内容密钥",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "视频详情返回的 playback_token",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "可选，Bearer {token}；令牌无效或已过期时按匿名访问",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/progress": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回各个清晰度和总体的转码百分比、处理速度和预计剩余时间 (eta_seconds，未知时为 -1)。\n请求头带 Accept: text/event-stream (或 ?stream=true) 时以 Server-Sent Events 持续推送 progress 事件，视频不再处于 transcoding 状态时推送最后一个事件并结束",
+                "produces": [
+                    "application/json",
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "查询转码进度",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "以 SSE 推送",
+                        "name": "stream",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TranscodeProgress"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/videos/{id}/subtitles/{language}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "上传某种语言的字幕文件 (SRT / WebVTT / ASS / SSA，UTF-8 编码，最大 2MB)，同一语言重复上传会覆盖。\n文件经过校验后统一转换为 WebVTT，并加入 HLS 自适应主播放列表的字幕组 (EXT-X-MEDIA TYPE=SUBTITLES)。仅所有者和管理员",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "上传字幕",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "BCP 47 语言标签，例如 en、zh-CN",
+                        "name": "language",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "字幕文件，格式按扩展名判断",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "播放器中显示的名称，默认为语言标签",
+                        "name": "label",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "字幕格式 (srt / vtt / ass / ssa)，扩展名无法判断时必填",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否为默认字幕",
+                        "name": "default",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.VideoSubtitle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除某种语言的字幕，并从 HLS 主播放列表的字幕组中移除。仅所有者和管理员",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "视频"
+                ],
+                "summary": "删除字幕",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "视频 ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "BCP 47 语言标签",
+                        "name": "language",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.CommentInfo": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Great video!"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-25T11:34:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "timeline": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "user": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "integer",
+                            "example": 2
+                        },
+                        "nickname": {
+                            "type": "string",
+                            "example": "Tom"
+                        }
+                    }
+                }
+            }
+        },
+        "handler.CompleteUploadRequest": {
+            "type": "object",
+            "required": [
+                "video_id"
+            ],
+            "properties": {
+                "sha256": {
+                    "description": "可选，原始文件的 SHA-256",
+                    "type": "string",
+                    "example": "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.CoverCandidate": {
+            "type": "object",
+            "properties": {
+                "brightness": {
+                    "description": "平均亮度 0-255",
+                    "type": "number",
+                    "example": 96.3
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "offset": {
+                    "description": "截取位置 (秒)",
+                    "type": "number",
+                    "example": 42.5
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                }
+            }
+        },
+        "handler.CoverCandidatesResponse": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.CoverCandidate"
+                    }
+                },
+                "cover_url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                }
+            }
+        },
+        "handler.CoverUploadResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "秒",
+                    "type": "integer",
+                    "example": 900
+                },
+                "upload_key": {
+                    "type": "string",
+                    "example": "covers/123/upload-9f86d081884c7d65"
+                },
+                "upload_url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                }
+            }
+        },
+        "handler.CreateCommentRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Great video!"
+                },
+                "timeline": {
+                    "description": "可选弹幕时间点（秒）",
+                    "type": "integer",
+                    "example": 15
+                }
+            }
+        },
+        "handler.DeadLetterListResponse": {
+            "type": "object",
+            "properties": {
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.DeadLetterTask"
+                    }
+                },
+                "total": {
+                    "description": "队列中的任务总数",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code 是可选的机器可读错误码，例如 file_too_large",
+                    "type": "string",
+                    "example": "file_too_large"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid request body"
+                }
+            }
+        },
+        "handler.ImportStatusResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "progress": {
+                    "description": "Progress 为 0-100 的百分比，大小未知时为 -1",
+                    "type": "number",
+                    "example": 50
+                },
+                "received_bytes": {
+                    "type": "integer",
+                    "example": 52428800
+                },
+                "source_url": {
+                    "type": "string",
+                    "example": "http://archive.internal/2019/keynote.mp4"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "downloading"
+                },
+                "total_bytes": {
+                    "description": "0 表示源站没有返回大小",
+                    "type": "integer",
+                    "example": 104857600
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.ImportVideoRequest": {
+            "type": "object",
+            "required": [
+                "source_url",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Imported from the archive"
+                },
+                "source_url": {
+                    "type": "string",
+                    "example": "http://archive.internal/2019/keynote.mp4"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "2019 Keynote"
+                }
+            }
+        },
+        "handler.ImportVideoResponse": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.InitiateMultipartUploadRequest": {
+            "type": "object",
+            "required": [
+                "file_name",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "A funny video"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "movie.mp4"
+                },
+                "file_size": {
+                    "description": "可选，用于计算合适的分片大小",
+                    "type": "integer",
+                    "example": 4294967296
+                },
+                "title": {
+                    "type": "string",
+                    "example": "My Movie"
+                }
+            }
+        },
+        "handler.InitiateMultipartUploadResponse": {
+            "type": "object",
+            "properties": {
+                "part_size": {
+                    "type": "integer",
+                    "example": 16777216
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "b5a6c1d2-..."
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.InitiateUploadRequest": {
+            "type": "object",
+            "required": [
+                "file_name",
+                "title"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "A funny video"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "movie.mp4"
+                },
+                "file_size": {
+                    "description": "可选，用于提前检查存储配额",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "title": {
+                    "type": "string",
+                    "example": "My Movie"
+                }
+            }
+        },
+        "handler.InitiateUploadResponse": {
+            "type": "object",
+            "properties": {
+                "upload_url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.ListPartsResponse": {
+            "type": "object",
+            "properties": {
+                "part_size": {
+                    "type": "integer",
+                    "example": 16777216
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.UploadedPart"
+                    }
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 123
+                }
+            }
+        },
+        "handler.ListVideosResponse": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "videos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.VideoInfo"
+                    }
+                }
+            }
+        },
+        "handler.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "tom@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "secret123"
+                }
+            }
+        },
+        "handler.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Login successful"
+                },
+                "nickname": {
+                    "description": "➜ 新增",
+                    "type": "string",
+                    "example": "TomCat"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "\u003cjwt\u003e"
+                },
+                "user_id": {
+                    "description": "➜ 新增",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Transcoding task has been submitted"
+                }
+            }
+        },
+        "handler.PresignPartResponse": {
+            "type": "object",
+            "properties": {
+                "part_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "upload_url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                }
+            }
+        },
+        "handler.QuotaRemaining": {
+            "type": "object",
+            "properties": {
+                "storage_bytes": {
+                    "type": "integer",
+                    "example": 5368709120
+                },
+                "videos_today": {
+                    "type": "integer",
+                    "example": 17
+                }
+            }
+        },
+        "handler.QuotaResponse": {
+            "type": "object",
+            "properties": {
+                "limits": {
+                    "$ref": "#/definitions/service.QuotaLimits"
+                },
+                "remaining": {
+                    "$ref": "#/definitions/handler.QuotaRemaining"
+                },
+                "usage": {
+                    "$ref": "#/definitions/service.QuotaUsage"
+                }
+            }
+        },
+        "handler.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "nickname",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "tom@example.com"
+                },
+                "nickname": {
+                    "type": "string",
+                    "example": "Tom"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "secret123"
+                }
+            }
+        },
+        "handler.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "User registered successfully"
+                },
+                "user_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.ReplayDeadLettersRequest": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "video_ids": {
+                    "description": "VideoIDs 为空时重新投递全部任务 (最多 limit 个)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        123,
+                        456
+                    ]
+                }
+            }
+        },
+        "handler.SetCoverRequest": {
+            "type": "object",
+            "properties": {
+                "candidate_id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "upload_key": {
+                    "type": "string",
+                    "example": "covers/123/upload-9f86d081884c7d65"
+                }
+            }
+        },
+        "handler.SetCoverResponse": {
+            "type": "object",
+            "properties": {
+                "cover_url": {
+                    "type": "string",
+                    "example": "https://minio.local/presigned-url"
+                }
+            }
+        },
+        "handler.SetWatermarkOptOutRequest": {
+            "type": "object",
+            "required": [
+                "opt_out"
+            ],
+            "properties": {
+                "opt_out": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handler.UploadedPart": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string",
+                    "example": "9b2cf535f27731c974343645a3985328"
+                },
+                "part_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 16777216
+                }
+            }
+        },
+        "handler.VideoDetailsResponse": {
+            "type": "object",
+            "properties": {
+                "loudness": {
+                    "description": "Loudness 为原始音频的响度测量结果，播放源已标准化到 target_lufs；未做标准化时省略",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.VideoLoudness"
+                        }
+                    ]
+                },
+                "metadata": {
+                    "description": "Metadata 为原始文件的元数据，仅所有者和管理员带令牌访问时返回",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.VideoMetadata"
+                        }
+                    ]
+                },
+                "playback_token": {
+                    "description": "PlaybackToken 为获取加密视频密钥的短期令牌，视频未加密时省略",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.PlaybackToken"
+                        }
+                    ]
+                },
+                "playback_url": {
+                    "description": "默认播放地址，优先为自适应码率主播放列表",
+                    "type": "string"
+                },
+                "sources": {},
+                "subtitles": {
+                    "description": "Subtitles 为字幕轨道 (WebVTT)，自适应主播放列表中同时包含对应的字幕组",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.VideoSubtitle"
+                    }
+                },
+                "video": {}
+            }
+        },
+        "handler.VideoInfo": {
+            "type": "object",
+            "properties": {
+                "cover_url": {
+                    "type": "string",
+                    "example": "https://example.com/cover.jpg"
                 },
                 "created_at": {
                     "type": "string",
-                    "example": "2025-06-25T11:34:00Z"
+                    "example": "2025-06-20T09:00:00Z"
                 },
-                "id": {
+                "description": {
+                    "type": "string",
+                    "example": "A short description"
+                },
+                "duration": {
                     "type": "integer",
-                    "example": 1
+                    "example": 3600
                 },
-                "timeline": {
+                "id": {
                     "type": "integer",
-                    "example": 15
+                    "example": 123
                 },
-                "user": {
-                    "type": "object",
-                    "properties": {
-                        "id": {
-                            "type": "integer",
-                            "example": 2
-                        },
-                        "nickname": {
-                            "type": "string",
-                            "example": "Tom"
-                        }
-                    }
+                "media_type": {
+                    "description": "video 或 audio (纯音频)",
+                    "type": "string",
+                    "example": "video"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "online"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "My Holiday"
                 }
             }
         },
-        "handler.CompleteUploadRequest": {
+        "model.StreamMetadata": {
             "type": "object",
-            "required": [
-                "video_id"
-            ],
             "properties": {
-                "video_id": {
-                    "type": "integer",
-                    "example": 123
+                "bit_rate": {
+                    "type": "integer"
+                },
+                "channels": {
+                    "type": "integer"
+                },
+                "codec": {
+                    "type": "string"
+                },
+                "frame_rate": {
+                    "type": "number"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "pixel_format": {
+                    "type": "string"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "rotation": {
+                    "type": "integer"
+                },
+                "sample_rate": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "video / audio / subtitle / data",
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
                 }
             }
         },
-        "handler.CreateCommentRequest": {
+        "model.VideoLoudness": {
             "type": "object",
-            "required": [
-                "content"
-            ],
             "properties": {
-                "content": {
-                    "type": "string",
-                    "example": "Great video!"
+                "created_at": {
+                    "type": "string"
                 },
-                "timeline": {
-                    "description": "可选弹幕时间点（秒）",
-                    "type": "integer",
-                    "example": 15
+                "integrated_lufs": {
+                    "description": "原始音频的综合响度",
+                    "type": "number"
+                },
+                "loudness_range": {
+                    "description": "原始音频的响度范围 (LU)",
+                    "type": "number"
+                },
+                "replay_gain_db": {
+                    "description": "ReplayGainDB 是播放源相对 ReplayGain 参考响度 (-18 LUFS) 需要的增益，播放器可以据此再统一调整音量",
+                    "type": "number"
+                },
+                "target_lufs": {
+                    "description": "标准化目标",
+                    "type": "number"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "true_peak_dbtp": {
+                    "description": "原始音频的真峰值",
+                    "type": "number"
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "model.VideoMetadata": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Invalid request body"
+                "audio_codec": {
+                    "description": "第一个音频流",
+                    "type": "string"
+                },
+                "bit_rate": {
+                    "description": "总码率 bit/s",
+                    "type": "integer"
+                },
+                "container": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "creation_time": {
+                    "type": "string"
+                },
+                "duration": {
+                    "description": "秒",
+                    "type": "number"
+                },
+                "frame_rate": {
+                    "type": "number"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "rotation": {
+                    "type": "integer"
+                },
+                "streams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.StreamMetadata"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_codec": {
+                    "description": "第一个视频流",
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "integer"
+                },
+                "width": {
+                    "type": "integer"
                 }
             }
         },
-        "handler.InitiateUploadRequest": {
+        "model.VideoSubtitle": {
             "type": "object",
-            "required": [
-                "file_name",
-                "title"
-            ],
             "properties": {
-                "description": {
-                    "type": "string",
-                    "example": "A funny video"
+                "created_at": {
+                    "type": "string"
                 },
-                "file_name": {
-                    "type": "string",
-                    "example": "movie.mp4"
+                "cue_count": {
+                    "type": "integer"
                 },
-                "title": {
-                    "type": "string",
-                    "example": "My Movie"
+                "id": {
+                    "type": "integer"
+                },
+                "is_default": {
+                    "type": "boolean"
+                },
+                "label": {
+                    "description": "播放器中显示的名称",
+                    "type": "string"
+                },
+                "language": {
+                    "description": "BCP 47，例如 en、zh-CN",
+                    "type": "string"
+                },
+                "playlist_url": {
+                    "description": "HLS 字幕媒体播放列表",
+                    "type": "string"
+                },
+                "source_format": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "WebVTT 对象路径",
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "integer"
                 }
             }
         },
-        "handler.InitiateUploadResponse": {
+        "service.DeadLetterTask": {
             "type": "object",
             "properties": {
-                "upload_url": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "failed_at": {
                     "type": "string",
-                    "example": "https://minio.local/presigned-url"
+                    "example": "2025-01-01T00:00:00Z"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "exit status 1"
                 },
                 "video_id": {
                     "type": "integer",
@@ -519,143 +2964,235 @@ const docTemplate = `{
                 }
             }
         },
-        "handler.ListVideosResponse": {
+        "service.MinioEvent": {
             "type": "object",
             "properties": {
-                "total": {
-                    "type": "integer",
-                    "example": 100
+                "EventName": {
+                    "type": "string"
                 },
-                "videos": {
+                "Key": {
+                    "type": "string"
+                },
+                "Records": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handler.VideoInfo"
+                        "$ref": "#/definitions/service.MinioEventRecord"
                     }
                 }
             }
         },
-        "handler.LoginRequest": {
+        "service.MinioEventRecord": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "tom@example.com"
+                "eventName": {
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "secret123"
+                "s3": {
+                    "type": "object",
+                    "properties": {
+                        "bucket": {
+                            "type": "object",
+                            "properties": {
+                                "name": {
+                                    "type": "string"
+                                }
+                            }
+                        },
+                        "object": {
+                            "type": "object",
+                            "properties": {
+                                "key": {
+                                    "type": "string"
+                                },
+                                "size": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    }
                 }
             }
         },
-        "handler.LoginResponse": {
+        "service.PlaybackToken": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Login successful"
-                },
-                "nickname": {
-                    "description": "➜ 新增",
-                    "type": "string",
-                    "example": "TomCat"
+                "expires_at": {
+                    "type": "string"
                 },
                 "token": {
                     "type": "string",
-                    "example": "\u003cjwt\u003e"
-                },
-                "user_id": {
-                    "description": "➜ 新增",
-                    "type": "integer",
-                    "example": 1
+                    "example": "eyJhbGciOiJIUzI1NiIs..."
                 }
             }
         },
-        "handler.MessageResponse": {
+        "service.ProfileProgress": {
             "type": "object",
             "properties": {
-                "message": {
+                "name": {
                     "type": "string",
-                    "example": "Transcoding task has been submitted"
+                    "example": "720p"
+                },
+                "percent": {
+                    "type": "number",
+                    "example": 42.5
                 }
             }
         },
-        "handler.RegisterRequest": {
+        "service.QuotaLimits": {
             "type": "object",
-            "required": [
-                "email",
-                "nickname",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "tom@example.com"
+                "max_duration_seconds": {
+                    "type": "integer"
                 },
-                "nickname": {
-                    "type": "string",
-                    "example": "Tom"
+                "max_storage_bytes": {
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
-                    "example": "secret123"
+                "max_videos_per_day": {
+                    "type": "integer"
                 }
             }
         },
-        "handler.RegisterResponse": {
+        "service.QuotaUsage": {
             "type": "object",
             "properties": {
-                "message": {
+                "storage_bytes": {
+                    "description": "videos.raw_size + video_sources.file_size",
+                    "type": "integer"
+                },
+                "videos_today": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.ReplayRejection": {
+            "type": "object",
+            "properties": {
+                "error": {
                     "type": "string",
-                    "example": "User registered successfully"
+                    "example": "raw object no longer exists, the video must be uploaded again"
                 },
-                "user_id": {
+                "video_id": {
                     "type": "integer",
-                    "example": 1
+                    "example": 123
                 }
             }
         },
-        "handler.VideoDetailsResponse": {
+        "service.ReplayResult": {
             "type": "object",
             "properties": {
-                "sources": {},
-                "video": {}
+                "rejected": {
+                    "description": "Rejected 为不能重新投递的任务: 原始文件已不存在的任务移出死信队列，视频标记为 failed；\n没有 object_key 或暂时无法检查原始文件的任务留在死信队列中",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ReplayRejection"
+                    }
+                },
+                "replayed": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        123
+                    ]
+                }
             }
         },
-        "handler.VideoInfo": {
+        "service.TranscodeProgress": {
             "type": "object",
             "properties": {
-                "cover_url": {
-                    "type": "string",
-                    "example": "https://example.com/cover.jpg"
+                "eta_seconds": {
+                    "description": "ETASeconds 为预计剩余时间，按当前速度估算，未知时为 -1",
+                    "type": "integer",
+                    "example": 95
                 },
-                "created_at": {
-                    "type": "string",
-                    "example": "2025-06-20T09:00:00Z"
+                "percent": {
+                    "description": "总体进度 0-100",
+                    "type": "number",
+                    "example": 47.5
                 },
-                "description": {
+                "profile": {
                     "type": "string",
-                    "example": "A short description"
+                    "example": "720p"
                 },
-                "duration": {
-                    "type": "integer",
-                    "example": 3600
+                "profiles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ProfileProgress"
+                    }
                 },
-                "id": {
-                    "type": "integer",
-                    "example": 123
+                "speed": {
+                    "description": "当前档位的处理速度 (相对实时播放的倍数)",
+                    "type": "number",
+                    "example": 2.3
                 },
                 "status": {
+                    "description": "视频状态，非 transcoding 时进度不再变化",
                     "type": "string",
-                    "example": "online"
+                    "example": "transcoding"
                 },
-                "title": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "service.Watermark": {
+            "type": "object",
+            "properties": {
+                "image": {
+                    "description": "水印图片的对象路径",
                     "type": "string",
-                    "example": "My Holiday"
+                    "example": "branding/logo.png"
+                },
+                "margin": {
+                    "description": "到画面边缘的距离，相对输出高度",
+                    "type": "number",
+                    "example": 0.03
+                },
+                "opacity": {
+                    "type": "number",
+                    "example": 0.8
+                },
+                "position": {
+                    "type": "string",
+                    "example": "bottom-right"
+                },
+                "scale": {
+                    "description": "水印高度相对输出高度",
+                    "type": "number",
+                    "example": 0.08
+                }
+            }
+        },
+        "service.WatermarkSettings": {
+            "type": "object",
+            "properties": {
+                "can_opt_out": {
+                    "description": "当前角色能否关闭水印",
+                    "type": "boolean",
+                    "example": false
+                },
+                "enabled": {
+                    "description": "平台是否启用了水印",
+                    "type": "boolean",
+                    "example": true
+                },
+                "opt_out": {
+                    "description": "是否已关闭水印 (角色不允许时不生效)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "watermark": {
+                    "description": "之后转码的视频实际叠加的水印，不叠加时为 null",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.Watermark"
+                        }
+                    ]
                 }
             }
         }
@@ -0,0 +1,118 @@
+#!/bin/bash
+
+# ==============================================================================
+#           视频平台后端 - 远程 URL 导入 (POST /videos/import) 测试脚本
+# ==============================================================================
+#
+# 用 python3 在本机起一个临时 HTTP 源站，验证白名单、404 源文件和完整导入流程。
+#
+# 使用方法:
+# 1. 确保 API Server 和 Worker 正在运行，并安装了 `curl`、`jq` 和 `python3`。
+# 2. 在 configs/config.yaml 的 import.allowed_hosts 中加入 "127.0.0.1:8089"
+#    (或者设置 IMPORT_PORT 并加入对应的 host:port)，然后重启 API 和 Worker。
+# 3. 设置 TEST_VIDEO_PATH 为一个测试视频文件。
+# 4. 运行 `bash import_test.sh`，全部通过时退出码为 0。
+#
+# ==============================================================================
+
+API_BASE_URL="http://localhost:8000/api/v1"
+TEST_VIDEO_PATH="${TEST_VIDEO_PATH:-$HOME/Downloads/test2.mp4}"
+IMPORT_PORT="${IMPORT_PORT:-8089}"
+SOURCE_BASE="http://127.0.0.1:$IMPORT_PORT"
+FAILED=0
+
+# --- 辅助函数 ---
+function print_header() {
+    echo ""
+    echo "=================================================="
+    echo "  $1"
+    echo "=================================================="
+}
+
+# expect <描述> <期望值> <实际值>
+function expect() {
+    if [ "$2" == "$3" ]; then
+        echo "PASS> $1 ($3)"
+    else
+        echo "FAIL> $1: expected $2, got $3"
+        FAILED=$((FAILED + 1))
+    fi
+}
+
+# import_video <URL>: 提交导入任务，输出 "<HTTP 状态码> <video_id>"
+function import_video() {
+    local body
+    body=$(curl -s -w "\n%{http_code}" -X POST "$API_BASE_URL/videos/import" \
+        -H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json" \
+        -d "{\"source_url\": \"$1\", \"title\": \"import test\"}")
+    echo "$(echo "$body" | tail -n1) $(echo "$body" | head -n -1 | jq -r '.video_id')"
+}
+
+# wait_import <video_id>: 轮询导入进度直到 completed / failed，输出最终状态
+function wait_import() {
+    local status
+    for _ in $(seq 1 60); do
+        status=$(curl -s "$API_BASE_URL/videos/$1/import" -H "Authorization: Bearer $TOKEN" | jq -r '.status')
+        if [ "$status" == "completed" ] || [ "$status" == "failed" ]; then
+            break
+        fi
+        sleep 2
+    done
+    echo "$status"
+}
+
+if [ ! -f "$TEST_VIDEO_PATH" ]; then
+    echo "测试视频文件不存在: $TEST_VIDEO_PATH"
+    exit 1
+fi
+
+# --- 临时源站 ---
+python3 -m http.server "$IMPORT_PORT" --bind 127.0.0.1 --directory "$(dirname "$TEST_VIDEO_PATH")" > /dev/null 2>&1 &
+SOURCE_PID=$!
+trap 'kill $SOURCE_PID 2>/dev/null' EXIT
+sleep 1
+
+EMAIL="import-$(date +%s%N)@example.com"
+curl -s -X POST "$API_BASE_URL/users/register" -H "Content-Type: application/json" \
+    -d "{\"nickname\": \"importer\", \"email\": \"$EMAIL\", \"password\": \"password123\"}" > /dev/null
+TOKEN=$(curl -s -X POST "$API_BASE_URL/users/login" -H "Content-Type: application/json" \
+    -d "{\"email\": \"$EMAIL\", \"password\": \"password123\"}" | jq -r '.token')
+if [ -z "$TOKEN" ] || [ "$TOKEN" == "null" ]; then
+    echo "无法注册/登录测试用户，请检查 API Server 是否正在运行。"
+    exit 1
+fi
+
+# ==============================================================================
+print_header "1. 不在白名单中的源站 (期望 400)"
+read -r CODE _ <<< "$(import_video "http://example.com/video.mp4")"
+expect "POST /videos/import (example.com)" 400 "$CODE"
+read -r CODE _ <<< "$(import_video "file:///etc/passwd")"
+expect "POST /videos/import (file://)" 400 "$CODE"
+
+# ==============================================================================
+print_header "2. 源文件不存在 (期望导入失败)"
+read -r CODE MISSING_ID <<< "$(import_video "$SOURCE_BASE/does-not-exist.mp4")"
+expect "POST /videos/import" 202 "$CODE"
+expect "导入状态" failed "$(wait_import "$MISSING_ID")"
+
+# ==============================================================================
+print_header "3. 完整导入并转码"
+FILE_NAME=$(basename "$TEST_VIDEO_PATH")
+read -r CODE VIDEO_ID <<< "$(import_video "$SOURCE_BASE/$FILE_NAME")"
+expect "POST /videos/import" 202 "$CODE"
+expect "导入状态" completed "$(wait_import "$VIDEO_ID")"
+
+VIDEO_STATUS=""
+for _ in $(seq 1 60); do
+    VIDEO_STATUS=$(curl -s "$API_BASE_URL/videos/$VIDEO_ID" | jq -r '.video.status')
+    [ "$VIDEO_STATUS" == "online" ] || [ "$VIDEO_STATUS" == "failed" ] && break
+    sleep 5
+done
+expect "视频状态" online "$VIDEO_STATUS"
+
+echo ""
+if [ "$FAILED" -gt 0 ]; then
+    echo "$FAILED 项检查失败"
+    exit 1
+fi
+echo "全部通过"
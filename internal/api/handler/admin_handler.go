@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// 单次查看 / 重新投递的死信任务数上限
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+)
+
+// DeadLetterListResponse 死信队列中的转码任务
+type DeadLetterListResponse struct {
+	Tasks []service.DeadLetterTask `json:"tasks"`
+	Total int                      `json:"total" example:"3"` // 队列中的任务总数
+}
+
+// ReplayDeadLettersRequest 重新投递死信任务的请求体
+type ReplayDeadLettersRequest struct {
+	// VideoIDs 为空时重新投递全部任务 (最多 limit 个)
+	VideoIDs []uint64 `json:"video_ids" example:"123,456"`
+	Limit    int      `json:"limit"     example:"50"`
+}
+
+// deadLetterLimit 把请求中的数量限制在 [1, maxDeadLetterLimit]，未指定时使用默认值
+func deadLetterLimit(limit int) int {
+	if limit <= 0 {
+		return defaultDeadLetterLimit
+	}
+	return min(limit, maxDeadLetterLimit)
+}
+
+// writeDeadLetterError 未配置死信队列时返回 404，其他错误返回 500
+func writeDeadLetterError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrDeadLetterDisabled) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+}
+
+// ListTranscodeDeadLetters godoc
+// @Summary      查看转码死信队列
+// @Description  返回重试耗尽的转码任务 (视频 ID、尝试次数、最后一次错误)，不会把任务移出队列。仅管理员可用
+// @Tags         管理
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        limit  query     int  false  "最多返回的任务数，默认 50"
+// @Success      200    {object}  DeadLetterListResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      403    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /admin/dlq/transcode [get]
+func ListTranscodeDeadLetters(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	tasks, total, err := service.ListDeadLettersService(deadLetterLimit(limit))
+	if err != nil {
+		writeDeadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, DeadLetterListResponse{Tasks: tasks, Total: total})
+}
+
+// ReplayTranscodeDeadLetters godoc
+// @Summary      重新投递转码死信任务
+// @Description  把死信队列中的任务重新发布到转码队列 (重试次数清零)，视频状态从 dead_lettered 恢复为 transcoding。
+// @Description  已删除或状态已变化的视频对应的任务会被丢弃；原始文件已不存在的任务无法重新转码，列在 rejected 中 (附错误原因) 并移出死信队列，视频标记为 failed。仅管理员可用
+// @Tags         管理
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ReplayDeadLettersRequest  false  "要重新投递的视频，为空时全部投递"
+// @Success      200   {object}  service.ReplayResult
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /admin/dlq/transcode/replay [post]
+func ReplayTranscodeDeadLetters(c *gin.Context) {
+	var req ReplayDeadLettersRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	result, err := service.ReplayDeadLettersService(req.VideoIDs, deadLetterLimit(req.Limit))
+	if err != nil {
+		writeDeadLetterError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
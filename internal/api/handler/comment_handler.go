@@ -43,6 +43,7 @@ type CommentInfo struct {
 // @Success      201   {object}  CommentInfo
 // @Failure      400   {object}  ErrorResponse
 // @Failure      401   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
 // @Failure      500   {object}  ErrorResponse
 // @Router       /videos/{id}/comments [post]
 func CreateComment(c *gin.Context) {
@@ -58,10 +59,13 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 
-	userIDVal, _ := c.Get("user_id")
-	userID := uint64(userIDVal.(float64))
+	// 视频必须对当前用户可见 (公开视频，或者自己的/管理员/审核员)
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionComment); !ok {
+		return
+	}
+	actor, _ := currentActor(c)
 
-	comment, err := service.CreateCommentService(userID, videoID, req.Content, req.Timeline)
+	comment, err := service.CreateCommentService(actor.UserID, videoID, req.Content, req.Timeline)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
@@ -117,3 +121,48 @@ func ListComments(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// DeleteComment godoc
+// @Summary      删除评论 / 弹幕
+// @Description  需要登录。评论作者、管理员或审核员可以删除
+// @Tags         评论
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id          path      int64  true  "视频 ID"
+// @Param        comment_id  path      int64  true  "评论 ID"
+// @Success      200         {object}  MessageResponse
+// @Failure      400         {object}  ErrorResponse
+// @Failure      401         {object}  ErrorResponse
+// @Failure      403         {object}  ErrorResponse
+// @Failure      404         {object}  ErrorResponse
+// @Failure      500         {object}  ErrorResponse
+// @Router       /videos/{id}/comments/{comment_id} [delete]
+func DeleteComment(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid comment ID"})
+		return
+	}
+
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+	if _, err := service.AuthorizeComment(actor, videoID, commentID); err != nil {
+		writePolicyError(c, err)
+		return
+	}
+
+	if err := service.DeleteCommentService(commentID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Comment deleted"})
+}
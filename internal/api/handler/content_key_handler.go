@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// GetVideoKey godoc
+// @Summary      获取加密视频的内容密钥
+// @Description  加密视频的 HLS 播放列表 (EXT-X-KEY) 指向该接口，返回 16 字节的 AES-128 密钥。调用者需要带视频详情返回的 playback_token (?token=，短期有效，只能用于该视频)，或者带有观看权限的 Bearer 令牌 (公开视频、所有者、管理员或审核员)。浏览器播放器通常无法为密钥请求附加 Authorization 头，应使用 playback_token，例如在 hls.js 的 xhrSetup 中为密钥地址追加 token 参数
+// @Tags         视频
+// @Produce      octet-stream
+// @Param        id     path      int64   true   "视频 ID"
+// @Param        token  query     string  false  "视频详情返回的 playback_token"
+// @Param        Authorization  header  string  false  "可选，Bearer {token}；令牌无效或已过期时按匿名访问"
+// @Success      200    {file}    binary
+// @Failure      400    {object}  ErrorResponse
+// @Failure      401    {object}  ErrorResponse
+// @Failure      404    {object}  ErrorResponse
+// @Failure      500    {object}  ErrorResponse
+// @Router       /videos/{id}/key [get]
+func GetVideoKey(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+
+	if token := c.Query("token"); token != "" {
+		if err := service.VerifyPlaybackToken(token, videoID); err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error()})
+			return
+		}
+	} else {
+		actor, ok := currentActor(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "A playback token or Authorization header is required"})
+			return
+		}
+		if _, err := service.AuthorizeVideo(actor, videoID, service.VideoActionView); err != nil {
+			writePolicyError(c, err)
+			return
+		}
+	}
+
+	key, err := service.GetContentKeyService(videoID)
+	if err != nil {
+		if errors.Is(err, service.ErrContentKeyNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// 密钥不能被共享缓存，也不应留在浏览器缓存中
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CoverCandidate 候选封面
+type CoverCandidate struct {
+	ID         uint64  `json:"id"         example:"12"`
+	URL        string  `json:"url"        example:"https://minio.local/presigned-url"`
+	Offset     float64 `json:"offset"     example:"42.5"` // 截取位置 (秒)
+	Brightness float64 `json:"brightness" example:"96.3"` // 平均亮度 0-255
+}
+
+// CoverCandidatesResponse 当前封面和候选封面
+type CoverCandidatesResponse struct {
+	CoverURL   string           `json:"cover_url" example:"https://minio.local/presigned-url"`
+	Candidates []CoverCandidate `json:"candidates"`
+}
+
+// CoverUploadResponse 自定义封面的预签名上传地址
+type CoverUploadResponse struct {
+	UploadURL string `json:"upload_url" example:"https://minio.local/presigned-url"`
+	UploadKey string `json:"upload_key" example:"covers/123/upload-9f86d081884c7d65"`
+	ExpiresIn int    `json:"expires_in" example:"900"` // 秒
+}
+
+// SetCoverRequest 设置封面的请求体，candidate_id 和 upload_key 二选一
+type SetCoverRequest struct {
+	CandidateID uint64 `json:"candidate_id" example:"12"`
+	UploadKey   string `json:"upload_key"   example:"covers/123/upload-9f86d081884c7d65"`
+}
+
+// SetCoverResponse 设置封面成功响应
+type SetCoverResponse struct {
+	CoverURL string `json:"cover_url" example:"https://minio.local/presigned-url"`
+}
+
+// ListVideoCovers godoc
+// @Summary      查看候选封面
+// @Description  返回当前封面和转码时截取的候选封面 (已跳过黑帧)，URL 为带签名的临时地址。仅所有者和管理员
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  CoverCandidatesResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/covers [get]
+func ListVideoCovers(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	video, ok := authorizeVideo(c, videoID, service.VideoActionManage)
+	if !ok {
+		return
+	}
+
+	candidates, err := service.ListCoverCandidatesService(video)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	coverURL, err := service.PresignCoverURL(video.CoverURL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	resp := CoverCandidatesResponse{CoverURL: coverURL, Candidates: make([]CoverCandidate, 0, len(candidates))}
+	for _, cand := range candidates {
+		resp.Candidates = append(resp.Candidates, CoverCandidate{
+			ID:         cand.ID,
+			URL:        cand.URL,
+			Offset:     cand.Offset,
+			Brightness: cand.Brightness,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PresignCoverUpload godoc
+// @Summary      获取自定义封面上传地址
+// @Description  生成预签名 PUT URL，客户端上传图片 (JPEG / PNG / WebP，大小上限见 cover.max_upload_size_mb) 后，
+// @Description  用返回的 upload_key 调用 PUT /videos/{id}/cover。转码完成前不能修改封面。仅所有者和管理员
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  CoverUploadResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/cover/upload [post]
+func PresignCoverUpload(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	video, ok := authorizeVideo(c, videoID, service.VideoActionManage)
+	if !ok {
+		return
+	}
+
+	upload, err := service.PresignCoverUploadService(video)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, CoverUploadResponse{
+		UploadURL: upload.UploadURL,
+		UploadKey: upload.UploadKey,
+		ExpiresIn: int(upload.ExpiresIn.Seconds()),
+	})
+}
+
+// SetVideoCover godoc
+// @Summary      设置封面
+// @Description  candidate_id 选择一张候选封面；upload_key 使用上传的自定义图片，服务端校验格式和尺寸，
+// @Description  缩放到 cover.max_width x cover.max_height 以内并重新编码为 JPEG。两者只能指定一个。仅所有者和管理员
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int64            true  "视频 ID"
+// @Param        body  body      SetCoverRequest  true  "候选封面或上传的图片"
+// @Success      200   {object}  SetCoverResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      409   {object}  ErrorResponse
+// @Failure      413   {object}  ErrorResponse
+// @Failure      415   {object}  ErrorResponse
+// @Failure      422   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /videos/{id}/cover [put]
+func SetVideoCover(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	var req SetCoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+	if (req.CandidateID == 0) == (req.UploadKey == "") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Exactly one of candidate_id and upload_key is required"})
+		return
+	}
+	video, ok := authorizeVideo(c, videoID, service.VideoActionManage)
+	if !ok {
+		return
+	}
+
+	var coverKey string
+	if req.CandidateID != 0 {
+		coverKey, err = service.SelectCoverCandidateService(video, req.CandidateID)
+	} else {
+		coverKey, err = service.SetCustomCoverService(c.Request.Context(), video, req.UploadKey)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrCoverCandidateNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		writeUploadError(c, err)
+		return
+	}
+
+	coverURL, err := service.PresignCoverURL(coverKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, SetCoverResponse{CoverURL: coverURL})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// minioWebhookAuthorized 校验 MinIO webhook 携带的令牌。
+// MinIO 对不含空格的 auth_token 会自动加上 "Bearer " 前缀，两种形式都接受。
+func minioWebhookAuthorized(c *gin.Context) bool {
+	token := config.AppConfig.MinIO.WebhookToken
+	if token == "" {
+		return false
+	}
+	got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// MinioWebhook godoc
+// @Summary      MinIO 存储桶事件通知
+// @Description  接收 MinIO 的 s3:ObjectCreated:* 事件，原始文件上传到 raw/ 后自动完成上传并提交转码，客户端无需再调用 /videos/upload/complete
+// @Tags         内部
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header    string               true  "MinIO webhook 令牌 (Bearer {token} 或 {token})"
+// @Param        body           body      service.MinioEvent   true  "MinIO 事件"
+// @Success      204
+// @Failure      400            {object}  ErrorResponse
+// @Failure      401            {object}  ErrorResponse
+// @Failure      500            {object}  ErrorResponse
+// @Router       /hooks/minio [post]
+func MinioWebhook(c *gin.Context) {
+	if !minioWebhookAuthorized(c) {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid webhook token"})
+		return
+	}
+
+	var event service.MinioEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid event body"})
+		return
+	}
+
+	// 返回非 2xx 时 MinIO 会稍后重新投递事件
+	if err := service.HandleMinioEventService(event); err != nil {
+		log.Printf("Failed to handle MinIO event %s: %v", event.Key, err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportVideoRequest 远程 URL 导入请求体
+type ImportVideoRequest struct {
+	SourceURL   string `json:"source_url"   binding:"required" example:"http://archive.internal/2019/keynote.mp4"`
+	Title       string `json:"title"        binding:"required" example:"2019 Keynote"`
+	Description string `json:"description"                     example:"Imported from the archive"`
+}
+
+// ImportVideoResponse 导入任务创建成功响应
+type ImportVideoResponse struct {
+	VideoID uint64 `json:"video_id" example:"123"`
+	Status  string `json:"status"   example:"pending"`
+}
+
+// ImportStatusResponse 导入任务进度
+type ImportStatusResponse struct {
+	VideoID       uint64 `json:"video_id"       example:"123"`
+	SourceURL     string `json:"source_url"     example:"http://archive.internal/2019/keynote.mp4"`
+	Status        string `json:"status"         example:"downloading"`
+	TotalBytes    uint64 `json:"total_bytes"    example:"104857600"` // 0 表示源站没有返回大小
+	ReceivedBytes uint64 `json:"received_bytes" example:"52428800"`
+	// Progress 为 0-100 的百分比，大小未知时为 -1
+	Progress float64 `json:"progress" example:"50"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// ImportVideo godoc
+// @Summary      从远程 URL 导入视频
+// @Description  创建视频记录并提交导入任务，由 Worker 从源站 (需在 import.allowed_hosts 白名单中) 下载到 MinIO，下载完成后自动校验并转码
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      ImportVideoRequest  true  "导入源和视频元数据"
+// @Success      202   {object}  ImportVideoResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /videos/import [post]
+func ImportVideo(c *gin.Context) {
+	var req ImportVideoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+
+	video, imp, err := service.ImportVideoService(actor, req.SourceURL, req.Title, req.Description)
+	if err != nil {
+		var uerr *service.ImportURLError
+		if errors.As(err, &uerr) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: uerr.Message, Code: "invalid_source_url"})
+			return
+		}
+		writeUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ImportVideoResponse{VideoID: video.ID, Status: imp.Status})
+}
+
+// GetImportStatus godoc
+// @Summary      查询导入进度
+// @Description  返回远程 URL 导入任务的状态和已下载字节数，仅视频所有者和管理员可查看
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  ImportStatusResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/import [get]
+func GetImportStatus(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	imp, err := service.GetImportService(videoID)
+	if err != nil {
+		if errors.Is(err, service.ErrImportNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	progress := float64(-1)
+	switch {
+	case imp.Status == "completed":
+		progress = 100
+	case imp.TotalBytes > 0:
+		progress = float64(imp.ReceivedBytes) * 100 / float64(imp.TotalBytes)
+	}
+
+	c.JSON(http.StatusOK, ImportStatusResponse{
+		VideoID:       imp.VideoID,
+		SourceURL:     imp.SourceURL,
+		Status:        imp.Status,
+		TotalBytes:    imp.TotalBytes,
+		ReceivedBytes: imp.ReceivedBytes,
+		Progress:      progress,
+		Error:         imp.Error,
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// currentActor 从 JWTAuthMiddleware 写入的 claims 中取出当前用户
+func currentActor(c *gin.Context) (service.Actor, bool) {
+	userIDVal, _ := c.Get("user_id")
+	userID, ok := userIDVal.(float64)
+	if !ok {
+		return service.Actor{}, false
+	}
+	roleVal, _ := c.Get("role")
+	role, _ := roleVal.(string)
+	return service.Actor{UserID: uint64(userID), Role: role}, true
+}
+
+// writePolicyError 把权限检查的错误统一转换为 401 / 403 / 404 响应
+func writePolicyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrVideoNotFound), errors.Is(err, service.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrForbidden):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
+}
+
+// authorizeVideo 检查当前用户能否对视频执行 action，失败时已写入响应，调用方直接 return 即可
+func authorizeVideo(c *gin.Context, videoID uint64, action service.VideoAction) (*model.Video, bool) {
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return nil, false
+	}
+	video, err := service.AuthorizeVideo(actor, videoID, action)
+	if err != nil {
+		writePolicyError(c, err)
+		return nil, false
+	}
+	return video, true
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWritePolicyError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{service.ErrVideoNotFound, http.StatusNotFound},
+		{service.ErrCommentNotFound, http.StatusNotFound},
+		{fmt.Errorf("load video: %w", service.ErrVideoNotFound), http.StatusNotFound},
+		{service.ErrForbidden, http.StatusForbidden},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		writePolicyError(c, tc.err)
+		if w.Code != tc.want {
+			t.Errorf("%v: got HTTP %d, want %d", tc.err, w.Code, tc.want)
+		}
+	}
+}
+
+func TestAuthorizeVideoRequiresClaims(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if _, ok := authorizeVideo(c, 1, service.VideoActionManage); ok {
+		t.Fatal("authorizeVideo succeeded without claims")
+	}
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("got HTTP %d, want 401", w.Code)
+	}
+}
+
+func TestCurrentActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if _, ok := currentActor(c); ok {
+		t.Fatal("anonymous request returned an actor")
+	}
+
+	// jwt.MapClaims 中的数字解析为 float64
+	c.Set("user_id", float64(42))
+	c.Set("role", "auditor")
+	actor, ok := currentActor(c)
+	if !ok || actor.UserID != 42 || !actor.IsAuditor() {
+		t.Fatalf("got %+v, %v", actor, ok)
+	}
+}
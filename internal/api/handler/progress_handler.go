@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// progressRecheckInterval 是 SSE 推送期间重新检查视频状态 (并发送心跳) 的间隔，
+// 用于发现 Worker 没有上报最终状态的情况 (转码失败、被 reaper 处理等)
+const progressRecheckInterval = 15 * time.Second
+
+// GetTranscodeProgress godoc
+// @Summary      查询转码进度
+// @Description  返回各个清晰度和总体的转码百分比、处理速度和预计剩余时间 (eta_seconds，未知时为 -1)。
+// @Description  请求头带 Accept: text/event-stream (或 ?stream=true) 时以 Server-Sent Events 持续推送 progress 事件，视频不再处于 transcoding 状态时推送最后一个事件并结束
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        id      path   int64  true   "视频 ID"
+// @Param        stream  query  bool   false  "以 SSE 推送"
+// @Success      200  {object}  service.TranscodeProgress
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/progress [get]
+func GetTranscodeProgress(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionView); !ok {
+		return
+	}
+
+	if c.Query("stream") != "true" && !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		progress, err := service.GetTranscodeProgressService(c.Request.Context(), videoID)
+		if err != nil {
+			writePolicyError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, progress)
+		return
+	}
+	streamTranscodeProgress(c, videoID)
+}
+
+// streamTranscodeProgress 以 SSE 推送转码进度，直到视频离开 transcoding 状态或客户端断开
+func streamTranscodeProgress(c *gin.Context, videoID uint64) {
+	ctx := c.Request.Context()
+
+	// 先订阅再读取当前进度，避免漏掉两者之间的更新
+	pubsub := dal.Redis.Subscribe(ctx, service.ProgressChannel(videoID))
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	progress, err := service.GetTranscodeProgressService(ctx, videoID)
+	if err != nil {
+		writePolicyError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭 Nginx 的响应缓冲
+
+	messages := pubsub.Channel()
+	ticker := time.NewTicker(progressRecheckInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		if progress != nil {
+			c.SSEvent("progress", progress)
+			if progress.Status != "transcoding" {
+				return false
+			}
+			progress = nil
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case msg, ok := <-messages:
+			if !ok {
+				return false
+			}
+			var update service.TranscodeProgress
+			if err := json.Unmarshal([]byte(msg.Payload), &update); err != nil {
+				return true
+			}
+			progress = &update
+		case <-ticker.C:
+			latest, err := service.GetTranscodeProgressService(ctx, videoID)
+			if err != nil {
+				return false
+			}
+			if latest.Status != "transcoding" {
+				progress = latest
+			} else {
+				// SSE 注释行作为心跳，防止代理断开空闲连接
+				io.WriteString(w, ": keep-alive\n\n")
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaRemaining 剩余可用额度，对应限制为 0 (不限制) 时为 -1
+type QuotaRemaining struct {
+	StorageBytes int64 `json:"storage_bytes" example:"5368709120"`
+	VideosToday  int   `json:"videos_today"  example:"17"`
+}
+
+// QuotaResponse 当前用户的配额、使用量和剩余额度
+type QuotaResponse struct {
+	Limits    service.QuotaLimits `json:"limits"`
+	Usage     service.QuotaUsage  `json:"usage"`
+	Remaining QuotaRemaining      `json:"remaining"`
+}
+
+// GetMyQuota godoc
+// @Summary      获取我的配额
+// @Description  返回当前用户的配额 (0 表示不限制)、已用存储和今日上传数量，以及剩余额度 (-1 表示不限制)
+// @Tags         用户
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  QuotaResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /me/quota [get]
+func GetMyQuota(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+
+	limits, usage, err := service.GetQuotaService(actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	remaining := QuotaRemaining{StorageBytes: -1, VideosToday: -1}
+	if limits.MaxStorageBytes > 0 {
+		remaining.StorageBytes = int64(limits.MaxStorageBytes) - int64(usage.StorageBytes)
+		if remaining.StorageBytes < 0 {
+			remaining.StorageBytes = 0
+		}
+	}
+	if limits.MaxVideosPerDay > 0 {
+		remaining.VideosToday = max(limits.MaxVideosPerDay-usage.VideosToday, 0)
+	}
+
+	c.JSON(http.StatusOK, QuotaResponse{Limits: limits, Usage: usage, Remaining: remaining})
+}
@@ -1,7 +1,31 @@
 // internal/api/handler/error_response.go
 package handler
 
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
 // ErrorResponse 统一错误响应
 type ErrorResponse struct {
 	Error string `json:"error" example:"Invalid request body"`
+	// Code 是可选的机器可读错误码，例如 file_too_large
+	Code string `json:"code,omitempty" example:"file_too_large"`
+}
+
+// writeUploadError 把上传相关的错误 (服务端校验失败、超出配额) 转换为结构化的 4xx，其余返回 500
+func writeUploadError(c *gin.Context, err error) {
+	var verr *service.UploadVerificationError
+	var qerr *service.QuotaExceededError
+	switch {
+	case errors.As(err, &verr):
+		c.JSON(verr.Status, ErrorResponse{Error: verr.Message, Code: verr.Code})
+	case errors.As(err, &qerr):
+		c.JSON(qerr.Status, ErrorResponse{Error: qerr.Message, Code: qerr.Code})
+	default:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	}
 }
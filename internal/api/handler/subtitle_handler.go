@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// UploadSubtitle godoc
+// @Summary      上传字幕
+// @Description  上传某种语言的字幕文件 (SRT / WebVTT / ASS / SSA，UTF-8 编码，最大 2MB)，同一语言重复上传会覆盖。
+// @Description  文件经过校验后统一转换为 WebVTT，并加入 HLS 自适应主播放列表的字幕组 (EXT-X-MEDIA TYPE=SUBTITLES)。仅所有者和管理员
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id        path      int64   true   "视频 ID"
+// @Param        language  path      string  true   "BCP 47 语言标签，例如 en、zh-CN"
+// @Param        file      formData  file    true   "字幕文件，格式按扩展名判断"
+// @Param        label     formData  string  false  "播放器中显示的名称，默认为语言标签"
+// @Param        format    formData  string  false  "字幕格式 (srt / vtt / ass / ssa)，扩展名无法判断时必填"
+// @Param        default   formData  bool    false  "是否为默认字幕"
+// @Success      200  {object}  model.VideoSubtitle
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/subtitles/{language} [put]
+func UploadSubtitle(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	video, ok := authorizeVideo(c, videoID, service.VideoActionManage)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Missing subtitle file"})
+		return
+	}
+	if fileHeader.Size > service.MaxSubtitleBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Subtitle file is too large", Code: "file_too_large"})
+		return
+	}
+	format := c.PostForm("format")
+	if format == "" {
+		format = service.SubtitleFormatFromFileName(fileHeader.Filename)
+	}
+	if format == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Unknown subtitle format, expected srt, vtt, ass or ssa", Code: "unsupported_format"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid subtitle file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxSubtitleBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid subtitle file"})
+		return
+	}
+	isDefault, _ := strconv.ParseBool(c.PostForm("default"))
+
+	subtitle, err := service.UploadSubtitleService(c.Request.Context(), video, service.SubtitleUpload{
+		Language: c.Param("language"),
+		Label:    c.PostForm("label"),
+		Format:   format,
+		Default:  isDefault,
+		Data:     data,
+	})
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subtitle)
+}
+
+// DeleteSubtitle godoc
+// @Summary      删除字幕
+// @Description  删除某种语言的字幕，并从 HLS 主播放列表的字幕组中移除。仅所有者和管理员
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id        path      int64   true  "视频 ID"
+// @Param        language  path      string  true  "BCP 47 语言标签"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id}/subtitles/{language} [delete]
+func DeleteSubtitle(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	video, ok := authorizeVideo(c, videoID, service.VideoActionManage)
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteSubtitleService(c.Request.Context(), video, c.Param("language")); err != nil {
+		if errors.Is(err, service.ErrSubtitleNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "Subtitle deleted"})
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/api/middleware"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// tus 1.0 协议 (https://tus.io/protocols/resumable-upload) 的实现，
+// 支持 core、creation 和 termination 三部分。数据最终写入服务端生成的 raw/<id>/<随机名>.<扩展名>，
+// 不足一个分片的尾部数据暂存在 tmp/tus/<id>.part。
+
+const tusExtensions = "creation,termination"
+
+// parseTusMetadata 解析 Upload-Metadata 头: "key base64value,key2 base64value2"
+func parseTusMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if parts[0] == "" {
+			continue
+		}
+		value := ""
+		if len(parts) == 2 {
+			if decoded, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				value = string(decoded)
+			}
+		}
+		meta[parts[0]] = value
+	}
+	return meta
+}
+
+// TusOptions godoc
+// @Summary      tus 能力发现
+// @Description  返回服务端支持的 tus 版本和扩展
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Success      204
+// @Router       /videos/upload/tus [options]
+func TusOptions(c *gin.Context) {
+	c.Header("Tus-Version", middleware.TusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Status(http.StatusNoContent)
+}
+
+// TusCreate godoc
+// @Summary      创建 tus 上传
+// @Description  tus creation 扩展。Upload-Metadata 中必须包含 filename，可选 title 和 description
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Param        Tus-Resumable    header  string  true   "1.0.0"
+// @Param        Upload-Length    header  int     true   "文件总大小 (字节)"
+// @Param        Upload-Metadata  header  string  false  "base64 编码的元数据"
+// @Success      201
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      429  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/upload/tus [post]
+func TusCreate(c *gin.Context) {
+	length, err := strconv.ParseUint(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid or missing Upload-Length header"})
+		return
+	}
+
+	meta := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	fileName := meta["filename"]
+	if fileName == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Upload-Metadata must contain filename"})
+		return
+	}
+	title := meta["title"]
+	if title == "" {
+		title = fileName
+	}
+
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+
+	video, err := service.CreateTusUploadService(actor, fileName, title, meta["description"], length)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.FormatUint(video.ID, 10))
+	c.Header("Upload-Offset", "0")
+	c.Status(http.StatusCreated)
+}
+
+// TusHead godoc
+// @Summary      查询 tus 上传进度
+// @Description  返回服务端已接收的字节数 (Upload-Offset)
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Param        id             path    int64   true  "视频 ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Success      200
+// @Failure      403
+// @Failure      404
+// @Router       /videos/upload/tus/{id} [head]
+func TusHead(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	session, err := service.GetTusUploadService(videoID)
+	if errors.Is(err, service.ErrTusUploadNotFound) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatUint(session.ReceivedBytes, 10))
+	c.Header("Upload-Length", strconv.FormatUint(session.FileSize, 10))
+	c.Status(http.StatusOK)
+}
+
+// TusPatch godoc
+// @Summary      上传 tus 数据块
+// @Description  从 Upload-Offset 处追加数据，写满 Upload-Length 后自动合并并提交转码
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Accept       application/offset+octet-stream
+// @Param        id             path    int64   true  "视频 ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Param        Upload-Offset  header  int     true  "本次数据的起始偏移"
+// @Success      204
+// @Failure      400  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      413  {object}  ErrorResponse
+// @Failure      415  {object}  ErrorResponse
+// @Failure      422  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/upload/tus/{id} [patch]
+func TusPatch(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if c.ContentType() != "application/offset+octet-stream" {
+		c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{Error: "Content-Type must be application/offset+octet-stream"})
+		return
+	}
+	offset, err := strconv.ParseUint(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid or missing Upload-Offset header"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	newOffset, err := service.WriteTusChunkService(videoID, offset, c.Request.Body)
+	c.Header("Upload-Offset", strconv.FormatUint(newOffset, 10))
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, service.ErrTusUploadNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrTusOffsetMismatch):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	case errors.Is(err, service.ErrTusUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: err.Error()})
+	default:
+		// 数据已全部到达，但没有通过完成上传时的服务端校验或配额检查
+		writeUploadError(c, err)
+	}
+}
+
+// TusDelete godoc
+// @Summary      终止 tus 上传
+// @Description  tus termination 扩展，清理已上传的数据并将视频标记为失败
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Param        id             path    int64   true  "视频 ID"
+// @Param        Tus-Resumable  header  string  true  "1.0.0"
+// @Success      204
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/upload/tus/{id} [delete]
+func TusDelete(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	err = service.TerminateTusUploadService(videoID)
+	if errors.Is(err, service.ErrTusUploadNotFound) || errors.Is(err, service.ErrUploadSessionNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
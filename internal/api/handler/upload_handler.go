@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ---------- 请求 / 响应 DTO ----------
+
+// InitiateMultipartUploadRequest 初始化分片上传请求体
+type InitiateMultipartUploadRequest struct {
+	FileName    string `json:"file_name"    binding:"required" example:"movie.mp4"`
+	Title       string `json:"title"        binding:"required" example:"My Movie"`
+	Description string `json:"description"                     example:"A funny video"`
+	FileSize    uint64 `json:"file_size"                       example:"4294967296"` // 可选，用于计算合适的分片大小
+}
+
+// InitiateMultipartUploadResponse 初始化分片上传成功响应
+type InitiateMultipartUploadResponse struct {
+	VideoID  uint64 `json:"video_id"  example:"123"`
+	UploadID string `json:"upload_id" example:"b5a6c1d2-..."`
+	PartSize uint64 `json:"part_size" example:"16777216"`
+}
+
+// PresignPartResponse 单个分片的预签名上传地址
+type PresignPartResponse struct {
+	PartNumber int    `json:"part_number" example:"1"`
+	UploadURL  string `json:"upload_url"  example:"https://minio.local/presigned-url"`
+}
+
+// UploadedPart 已上传的分片信息
+type UploadedPart struct {
+	PartNumber int    `json:"part_number" example:"1"`
+	Size       int64  `json:"size"        example:"16777216"`
+	ETag       string `json:"etag"        example:"9b2cf535f27731c974343645a3985328"`
+}
+
+// ListPartsResponse 已上传分片列表响应
+type ListPartsResponse struct {
+	VideoID  uint64         `json:"video_id"  example:"123"`
+	PartSize uint64         `json:"part_size" example:"16777216"`
+	Parts    []UploadedPart `json:"parts"`
+}
+
+// ---------- 处理器 ----------
+
+// InitiateMultipartUpload godoc
+// @Summary      初始化分片上传
+// @Description  创建视频记录并开启 MinIO 分片上传会话，适用于大文件和断点续传
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      InitiateMultipartUploadRequest  true  "上传元数据"
+// @Success      200   {object}  InitiateMultipartUploadResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /videos/upload/multipart/initiate [post]
+func InitiateMultipartUpload(c *gin.Context) {
+	var req InitiateMultipartUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+
+	video, session, err := service.InitiateMultipartUploadService(
+		actor,
+		req.FileName,
+		req.Title,
+		req.Description,
+		req.FileSize,
+	)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, InitiateMultipartUploadResponse{
+		VideoID:  video.ID,
+		UploadID: session.UploadID,
+		PartSize: session.PartSize,
+	})
+}
+
+// PresignUploadPart godoc
+// @Summary      获取分片上传地址
+// @Description  为指定序号的分片生成预签名 PUT URL，客户端上传后需保留响应头中的 ETag
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id    path      int64  true  "视频 ID"
+// @Param        part  path      int    true  "分片序号 (1-10000)"
+// @Success      200   {object}  PresignPartResponse
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /videos/upload/multipart/{id}/parts/{part} [get]
+func PresignUploadPart(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid part number"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	uploadURL, err := service.PresignUploadPartService(videoID, partNumber)
+	if err != nil {
+		writeMultipartError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	c.JSON(http.StatusOK, PresignPartResponse{
+		PartNumber: partNumber,
+		UploadURL:  uploadURL,
+	})
+}
+
+// ListUploadedParts godoc
+// @Summary      列出已上传的分片
+// @Description  断点续传时用于查询哪些分片已经上传成功
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  ListPartsResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/upload/multipart/{id}/parts [get]
+func ListUploadedParts(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	session, parts, err := service.ListUploadedPartsService(videoID)
+	if err != nil {
+		writeMultipartError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := ListPartsResponse{
+		VideoID:  videoID,
+		PartSize: session.PartSize,
+		Parts:    make([]UploadedPart, 0, len(parts)),
+	}
+	for _, p := range parts {
+		resp.Parts = append(resp.Parts, UploadedPart{
+			PartNumber: p.PartNumber,
+			Size:       p.Size,
+			ETag:       p.ETag,
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AbortMultipartUpload godoc
+// @Summary      取消分片上传
+// @Description  放弃本次上传，清理已上传的分片并将视频标记为失败
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/upload/multipart/{id} [delete]
+func AbortMultipartUpload(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	if err := service.AbortMultipartUploadService(videoID); err != nil {
+		writeMultipartError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Multipart upload aborted"})
+}
+
+// writeMultipartError 没有进行中的分片上传会话时返回 404，其余错误返回 status
+func writeMultipartError(c *gin.Context, err error, status int) {
+	if errors.Is(err, service.ErrUploadSessionNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(status, ErrorResponse{Error: err.Error()})
+}
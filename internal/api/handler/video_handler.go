@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/cjh/video-platform-go/internal/dal/model"
 	"github.com/cjh/video-platform-go/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -17,6 +19,7 @@ type InitiateUploadRequest struct {
     FileName    string `json:"file_name"    binding:"required" example:"movie.mp4"`
     Title       string `json:"title"        binding:"required" example:"My Movie"`
     Description string `json:"description"                     example:"A funny video"`
+    FileSize    uint64 `json:"file_size"                       example:"10485760"` // 可选，用于提前检查存储配额
 }
 
 // InitiateUploadResponse 初始化上传成功响应
@@ -28,6 +31,7 @@ type InitiateUploadResponse struct {
 // CompleteUploadRequest 完成上传请求体
 type CompleteUploadRequest struct {
 	VideoID uint64 `json:"video_id" binding:"required" example:"123"`
+	SHA256  string `json:"sha256"   example:"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"` // 可选，原始文件的 SHA-256
 }
 
 // MessageResponse 通用消息响应
@@ -42,6 +46,7 @@ type VideoInfo struct {
 	Description string    `json:"description" example:"A short description"`
 	CoverURL    string    `json:"cover_url"   example:"https://example.com/cover.jpg"`
 	Status      string    `json:"status"      example:"online"`
+	MediaType   string    `json:"media_type"  example:"video"` // video 或 audio (纯音频)
 	Duration    uint      `json:"duration"    example:"3600"`
 	CreatedAt   time.Time `json:"created_at"  example:"2025-06-20T09:00:00Z"`
 }
@@ -54,8 +59,17 @@ type ListVideosResponse struct {
 
 // VideoDetailsResponse 视频详情响应
 type VideoDetailsResponse struct {
-	Video   any `json:"video"`
-	Sources any `json:"sources"`
+	Video       any    `json:"video"`
+	Sources     any    `json:"sources"`
+	PlaybackURL string `json:"playback_url"` // 默认播放地址，优先为自适应码率主播放列表
+	// Subtitles 为字幕轨道 (WebVTT)，自适应主播放列表中同时包含对应的字幕组
+	Subtitles []model.VideoSubtitle `json:"subtitles"`
+	// Loudness 为原始音频的响度测量结果，播放源已标准化到 target_lufs；未做标准化时省略
+	Loudness *model.VideoLoudness `json:"loudness,omitempty"`
+	// Metadata 为原始文件的元数据，仅所有者和管理员带令牌访问时返回
+	Metadata *model.VideoMetadata `json:"metadata,omitempty"`
+	// PlaybackToken 为获取加密视频密钥的短期令牌，视频未加密时省略
+	PlaybackToken *service.PlaybackToken `json:"playback_token,omitempty"`
 }
 
 // ---------- 处理器 ----------
@@ -71,6 +85,8 @@ type VideoDetailsResponse struct {
 // @Success      200   {object}  InitiateUploadResponse
 // @Failure      400   {object}  ErrorResponse
 // @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      429   {object}  ErrorResponse
 // @Failure      500   {object}  ErrorResponse
 // @Router       /videos/upload/initiate [post]
 func InitiateUpload(c *gin.Context) {
@@ -80,22 +96,21 @@ func InitiateUpload(c *gin.Context) {
         return
     }
 
-    userIDVal, _ := c.Get("user_id")
-    userID, ok := userIDVal.(float64)
+    actor, ok := currentActor(c)
     if !ok {
         c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
         return
     }
 
-    // *** 传 4 个参数 ***
     url, video, err := service.InitiateUploadService(
-        uint64(userID),
+        actor,
         req.FileName,
         req.Title,
         req.Description,
+        req.FileSize,
     )
     if err != nil {
-        c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+        writeUploadError(c, err)
         return
     }
 
@@ -107,7 +122,9 @@ func InitiateUpload(c *gin.Context) {
 
 // CompleteUpload godoc
 // @Summary      完成视频上传
-// @Description  通知后端开始转码
+// @Description  通知后端开始转码；如果是分片上传，会先合并所有已上传的分片。
+// @Description  提交前会校验对象是否存在、大小、Content-Type、可选的 SHA-256 以及 ffprobe 能否解析；
+// @Description  ffprobe 超时或无法读取对象时返回 503 (code 为 probe_unavailable)，视频保持 uploading，可以稍后重试
 // @Tags         视频
 // @Security     ApiKeyAuth
 // @Accept       json
@@ -115,7 +132,15 @@ func InitiateUpload(c *gin.Context) {
 // @Param        body  body      CompleteUploadRequest  true  "视频 ID"
 // @Success      200   {object}  MessageResponse
 // @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      404   {object}  ErrorResponse
+// @Failure      409   {object}  ErrorResponse
+// @Failure      413   {object}  ErrorResponse
+// @Failure      415   {object}  ErrorResponse
+// @Failure      422   {object}  ErrorResponse
 // @Failure      500   {object}  ErrorResponse
+// @Failure      503   {object}  ErrorResponse
 // @Router       /videos/upload/complete [post]
 func CompleteUpload(c *gin.Context) {
 	var req CompleteUploadRequest
@@ -124,8 +149,13 @@ func CompleteUpload(c *gin.Context) {
 		return
 	}
 
-	if err := service.CompleteUploadService(req.VideoID); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+	// 只有视频所有者 (或管理员) 才能提交转码
+	if _, ok := authorizeVideo(c, req.VideoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	if err := service.CompleteUploadService(req.VideoID, req.SHA256); err != nil {
+		writeUploadError(c, err)
 		return
 	}
 
@@ -160,6 +190,7 @@ func ListVideos(c *gin.Context) {
 			Description: v.Description,
 			CoverURL:    v.CoverURL,
 			Status:      v.Status,
+			MediaType:   v.MediaType,
 			Duration:    v.Duration,
 			CreatedAt:   v.CreatedAt,
 		})
@@ -173,9 +204,11 @@ func ListVideos(c *gin.Context) {
 
 // GetVideoDetails godoc
 // @Summary      获取视频详情
+// @Description  sources 中每个清晰度有两条播放源: format 为 HLS (master.m3u8) 和 DASH (manifest.mpd)，共享同一组 CMAF (fMP4) 分片；quality 为 auto 的 HLS 播放源是包含所有清晰度的自适应码率主播放列表，同时作为 playback_url 返回。thumbnail_vtt_url 为进度条预览图的 WebVTT 轨道 (cue 指向雪碧图中的区域 #xywh=x,y,w,h)，未生成时为空。subtitles 列出各语言的字幕轨道 (url 为 WebVTT 文件，playlist_url 为 HLS 字幕播放列表)，有字幕且带 SUBTITLES 字幕组的主播放列表已生成时 playback_url 指向它。media_type 为 audio 时是纯音频 (播放源只有 AAC 音频，没有预览图)，waveform_url 为波形数据 (audiowaveform JSON 格式，data 为每个点的最小值和最大值)。loudness 为原始音频的 EBU R128 响度测量值，播放源已标准化到 target_lufs，replay_gain_db 为相对 ReplayGain 参考响度 (-18 LUFS) 的增益，未做标准化时省略。启用加密后转码的视频只有 HLS 播放源 (AES-128 加密的 TS 分片)，没有 DASH；此时返回 playback_token (短期有效)，播放器请求播放列表中的密钥地址 (/videos/{id}/key) 时以 ?token= 附加。所有者和管理员带令牌访问时额外返回 metadata (原始文件的容器、各个流的编码、分辨率、帧率、码率等)
 // @Tags         视频
 // @Produce      json
 // @Param        id   path      int64  true  "视频 ID"
+// @Param        Authorization  header  string  false  "可选，Bearer {token}；令牌无效或已过期时按匿名访问"
 // @Success      200  {object}  VideoDetailsResponse
 // @Failure      400  {object}  ErrorResponse
 // @Failure      404  {object}  ErrorResponse
@@ -193,8 +226,77 @@ func GetVideoDetails(c *gin.Context) {
 		return
 	}
 
+	// 匿名访问时 actor 为零值，不会匹配任何视频的所有者
+	actor, _ := currentActor(c)
+	metadata, err := service.GetVideoMetadataService(actor, video)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	subtitles, err := service.ListSubtitlesService(videoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	loudness, err := service.GetVideoLoudnessService(videoID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	playbackToken, err := service.IssuePlaybackTokenService(actor, video)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, VideoDetailsResponse{
-		Video:   *video,
-		Sources: sources,
+		Video:         *video,
+		Sources:       sources,
+		PlaybackURL:   service.PrimaryPlaybackURL(sources),
+		Subtitles:     subtitles,
+		Loudness:      loudness,
+		Metadata:      metadata,
+		PlaybackToken: playbackToken,
 	})
 }
+
+// DeleteVideo godoc
+// @Summary      删除视频
+// @Description  删除视频及其评论和播放源，仅所有者和管理员可操作。与其他视频共享的转码产物在最后一个引用被删除时才会清理
+// @Tags         视频
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Param        id   path      int64  true  "视频 ID"
+// @Success      200  {object}  MessageResponse
+// @Failure      400  {object}  ErrorResponse
+// @Failure      401  {object}  ErrorResponse
+// @Failure      403  {object}  ErrorResponse
+// @Failure      404  {object}  ErrorResponse
+// @Failure      409  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /videos/{id} [delete]
+func DeleteVideo(c *gin.Context) {
+	videoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid video ID"})
+		return
+	}
+	if _, ok := authorizeVideo(c, videoID, service.VideoActionManage); !ok {
+		return
+	}
+
+	if err := service.DeleteVideoService(videoID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrVideoBusy):
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		default:
+			writePolicyError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{Message: "Video deleted"})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SetWatermarkOptOutRequest 关闭 / 恢复水印的请求体
+type SetWatermarkOptOutRequest struct {
+	OptOut *bool `json:"opt_out" binding:"required" example:"true"`
+}
+
+// GetMyWatermark godoc
+// @Summary      获取我的水印设置
+// @Description  返回平台是否启用水印、当前角色能否关闭水印，以及之后转码的视频实际叠加的水印 (图片、位置、边距、不透明度、缩放比例，不叠加时为 null)
+// @Tags         用户
+// @Security     ApiKeyAuth
+// @Produce      json
+// @Success      200  {object}  service.WatermarkSettings
+// @Failure      401  {object}  ErrorResponse
+// @Failure      500  {object}  ErrorResponse
+// @Router       /me/watermark [get]
+func GetMyWatermark(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+
+	settings, err := service.GetWatermarkSettingsService(actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetMyWatermarkOptOut godoc
+// @Summary      关闭或恢复水印
+// @Description  opt_out 为 true 时，之后转码的视频不再叠加平台水印，仅 watermark.opt_out_roles 中的角色可以关闭；
+// @Description  已经转码完成的视频不受影响
+// @Tags         用户
+// @Security     ApiKeyAuth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      SetWatermarkOptOutRequest  true  "是否关闭水印"
+// @Success      200   {object}  service.WatermarkSettings
+// @Failure      400   {object}  ErrorResponse
+// @Failure      401   {object}  ErrorResponse
+// @Failure      403   {object}  ErrorResponse
+// @Failure      500   {object}  ErrorResponse
+// @Router       /me/watermark [put]
+func SetMyWatermarkOptOut(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid user ID in token"})
+		return
+	}
+	var req SetWatermarkOptOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request body"})
+		return
+	}
+
+	settings, err := service.SetWatermarkOptOutService(actor, *req.OptOut)
+	if err != nil {
+		writePolicyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
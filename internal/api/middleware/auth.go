@@ -20,36 +20,63 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
 			return
 		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+		if !setClaims(c, authHeader) {
 			return
 		}
+		c.Next()
+	}
+}
 
-		tokenString := parts[1]
-
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
+// OptionalJWTAuthMiddleware 用于公开接口: 没有 Authorization 头时按匿名访问继续处理，
+// 带了有效令牌则和 JWTAuthMiddleware 一样写入 user_id / role；令牌无效或已过期时同样按匿名访问处理，
+// 公开内容不会因为客户端缓存了过期令牌而无法访问
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+			if claims, err := parseClaims(authHeader); err == nil {
+				c.Set("user_id", claims["user_id"])
+				c.Set("role", claims["role"])
 			}
-			return []byte(config.AppConfig.JWT.Secret), nil
-		})
-
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
-			return
 		}
+		c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// 将用户信息存入 context，方便后续 handler 使用
-			c.Set("user_id", claims["user_id"])
-			c.Set("role", claims["role"])
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			return
+// setClaims 校验 Bearer 令牌并把用户信息存入 context，失败时已写入 401 响应并返回 false
+func setClaims(c *gin.Context, authHeader string) bool {
+	claims, err := parseClaims(authHeader)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return false
+	}
+	// 将用户信息存入 context，方便后续 handler 使用
+	c.Set("user_id", claims["user_id"])
+	c.Set("role", claims["role"])
+	return true
+}
+
+// parseClaims 校验 Bearer 令牌并返回其中的 claims
+func parseClaims(authHeader string) (jwt.MapClaims, error) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, errors.New("Authorization header format must be Bearer {token}")
+	}
+
+	tokenString := parts[1]
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
 		}
+		return []byte(config.AppConfig.JWT.Secret), nil
+	})
 
-		c.Next()
+	if err != nil {
+		return nil, errors.New("Invalid token: " + err.Error())
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return claims, nil
 	}
-}
\ No newline at end of file
+	return nil, errors.New("Invalid token claims")
+}
@@ -0,0 +1,24 @@
+// internal/api/middleware/tus.go
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TusVersion 是服务端支持的 tus 协议版本
+const TusVersion = "1.0.0"
+
+// TusResumableHeader 校验并回写 Tus-Resumable 头，挂在 tus 路由组上
+func TusResumableHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Tus-Resumable", TusVersion)
+		if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != TusVersion {
+			c.Header("Tus-Version", TusVersion)
+			c.AbortWithStatus(http.StatusPreconditionFailed)
+			return
+		}
+		c.Next()
+	}
+}
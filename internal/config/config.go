@@ -9,10 +9,11 @@ import (
 // 全局配置变量
 var AppConfig Config
 
-// Profile 定义了一个转码配置
-type Profile struct {
-	Name       string `mapstructure:"name"`
-	Resolution string `mapstructure:"resolution"`
+// QuotaLimit 定义一个角色的默认配额，0 表示不限制
+type QuotaLimit struct {
+	MaxStorageMB       int64 `mapstructure:"max_storage_mb"`
+	MaxVideosPerDay    int   `mapstructure:"max_videos_per_day"`
+	MaxDurationSeconds int   `mapstructure:"max_duration_seconds"`
 }
 
 // Config 结构体，与 config.yaml 文件对应
@@ -42,13 +43,64 @@ type Config struct {
 		SecretAccessKey string `mapstructure:"secret_access_key"`
 		UseSSL          bool   `mapstructure:"use_ssl"`
 		BucketName      string `mapstructure:"bucket_name"`
+		// WebhookToken 是 MinIO 事件通知 webhook 的鉴权令牌，为空时不接受 webhook 通知
+		WebhookToken string `mapstructure:"webhook_token"`
 	} `mapstructure:"minio"`
+	Upload struct {
+		PresignExpireHours int `mapstructure:"presign_expire_hours"`
+		PartSizeMB         int `mapstructure:"part_size_mb"`
+		// 以下用于“完成上传”时的服务端校验
+		MaxFileSizeMB       int64    `mapstructure:"max_file_size_mb"`
+		AllowedContentTypes []string `mapstructure:"allowed_content_types"`
+		ProbeTimeoutSeconds int      `mapstructure:"probe_timeout_seconds"`
+	} `mapstructure:"upload"`
+	Import struct {
+		// AllowedHosts 允许导入的源站，支持 "media.example.com"、"*.example.com" 和带端口的 "127.0.0.1:8080"；为空时禁止导入
+		AllowedHosts   []string `mapstructure:"allowed_hosts"`
+		TimeoutMinutes int      `mapstructure:"timeout_minutes"`
+		// 大小上限和允许的 Content-Type 与普通上传共用 upload.max_file_size_mb / upload.allowed_content_types
+	} `mapstructure:"import"`
+	Worker WorkerConfig `mapstructure:"worker"`
+	Reaper struct {
+		// IntervalMinutes 为清理任务的执行间隔，0 表示不启用
+		IntervalMinutes int `mapstructure:"interval_minutes"`
+		// TranscodeDeadlineMinutes 转码中的视频超过该时间没有心跳 (Worker 每分钟刷新一次) 视为 Worker 已退出。
+		// 等待重试和在队列中排队的任务没有心跳，需大于最长的重试延迟和排队时间
+		TranscodeDeadlineMinutes int `mapstructure:"transcode_deadline_minutes"`
+		// StuckTranscodeAction 卡住的转码如何处理: requeue (重新入队) 或 fail (标记失败)
+		StuckTranscodeAction string `mapstructure:"stuck_transcode_action"`
+	} `mapstructure:"reaper"`
+	Quota struct {
+		// Roles 按角色 (user / auditor / admin) 配置默认配额，单个用户可在 user_quotas 表中覆盖
+		Roles map[string]QuotaLimit `mapstructure:"roles"`
+	} `mapstructure:"quota"`
 	RabbitMQ struct {
 		URL            string `mapstructure:"url"`
 		TranscodeQueue string `mapstructure:"transcode_queue"`
+		ImportQueue    string `mapstructure:"import_queue"`
+		// 转码失败后按 transcode_retry_delays_seconds 延迟重试 (第 n 次重试用第 n 个延迟，超出时用最后一个)，
+		// 共尝试 transcode_max_attempts 次后转入死信队列 transcode_dead_letter_queue，并把视频标记为 dead_lettered
+		// (没有配置死信队列时标记为 failed)
+		TranscodeMaxAttempts        int    `mapstructure:"transcode_max_attempts"`
+		TranscodeRetryDelaysSeconds []int  `mapstructure:"transcode_retry_delays_seconds"`
+		TranscodeDeadLetterQueue    string `mapstructure:"transcode_dead_letter_queue"`
+		// MinIO 通过 AMQP 目标投递的上传事件，为空时 Worker 不消费
+		UploadEventsExchange string `mapstructure:"upload_events_exchange"`
+		UploadEventsQueue    string `mapstructure:"upload_events_queue"`
 	} `mapstructure:"rabbitmq"`
+	Cover CoverConfig `mapstructure:"cover"`
+	// Watermark 为每个转码档位叠加的平台水印
+	Watermark WatermarkConfig `mapstructure:"watermark"`
+	// Encryption 为 HLS 加密和密钥分发
+	Encryption EncryptionConfig `mapstructure:"encryption"`
 	FFMpeg struct {
-		Profiles []Profile `mapstructure:"profiles"`
+		Profiles   []Profile       `mapstructure:"profiles"`
+		Thumbnails ThumbnailConfig `mapstructure:"thumbnails"`
+		// 纯音频上传 (没有视频流) 使用 AudioProfiles 转码为多个码率的 AAC，并生成波形数据
+		AudioProfiles []AudioProfile `mapstructure:"audio_profiles"`
+		Waveform      WaveformConfig `mapstructure:"waveform"`
+		// Loudness 启用后先测量原始音频的响度，再在每个档位的音频编码前做标准化
+		Loudness LoudnessConfig `mapstructure:"loudness"`
 	} `mapstructure:"ffmpeg"`
 }
 
@@ -65,4 +117,30 @@ func Init() {
 	if err := viper.Unmarshal(&AppConfig); err != nil {
 		log.Fatalf("Unable to decode into struct, %v", err)
 	}
+
+	// 转码配置错误要在启动时暴露，而不是等到第一个转码任务失败
+	if err := validateProfiles(AppConfig.FFMpeg.Profiles); err != nil {
+		log.Fatalf("Invalid transcode profiles: %v", err)
+	}
+	if err := validateAudioProfiles(AppConfig.FFMpeg.AudioProfiles, AppConfig.FFMpeg.Profiles, &AppConfig.FFMpeg.Waveform); err != nil {
+		log.Fatalf("Invalid audio profiles: %v", err)
+	}
+	if err := validateLoudness(&AppConfig.FFMpeg.Loudness); err != nil {
+		log.Fatalf("Invalid loudness config: %v", err)
+	}
+	if err := validateThumbnails(&AppConfig.FFMpeg.Thumbnails); err != nil {
+		log.Fatalf("Invalid thumbnail config: %v", err)
+	}
+	if err := validateCover(&AppConfig.Cover); err != nil {
+		log.Fatalf("Invalid cover config: %v", err)
+	}
+	if err := validateWatermark(&AppConfig.Watermark); err != nil {
+		log.Fatalf("Invalid watermark config: %v", err)
+	}
+	if err := validateEncryption(&AppConfig.Encryption); err != nil {
+		log.Fatalf("Invalid encryption config: %v", err)
+	}
+	if err := validateWorker(&AppConfig.Worker); err != nil {
+		log.Fatalf("Invalid worker config: %v", err)
+	}
 }
\ No newline at end of file
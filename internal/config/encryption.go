@@ -0,0 +1,57 @@
+// internal/config/encryption.go
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// EncryptionConfig 定义 HLS AES-128 加密和密钥分发
+type EncryptionConfig struct {
+	// Enabled 启用后，之后转码的视频输出为 AES-128 加密的 HLS (TS 分片)，不再生成 DASH，也不与其他视频共享转码产物
+	Enabled bool `mapstructure:"enabled"`
+	// MasterKey 为 base64 编码的 32 字节密钥，用 AES-256-GCM 加密保存在 video_keys 表中的内容密钥。
+	// 修改后已有的内容密钥无法解密，视频需要重新转码
+	MasterKey string `mapstructure:"master_key"`
+	// KeyURLBase 为播放列表中密钥地址的前缀 (API 的外部地址)，密钥地址为 <key_url_base>/videos/<id>/key
+	KeyURLBase string `mapstructure:"key_url_base"`
+	// PlaybackTokenTTLSeconds 为视频详情返回的播放令牌的有效期，默认 300
+	PlaybackTokenTTLSeconds int `mapstructure:"playback_token_ttl_seconds"`
+}
+
+// MasterKeyBytes 返回解码后的主密钥
+func (e *EncryptionConfig) MasterKeyBytes() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(e.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("encryption.master_key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption.master_key must decode to 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// validateEncryption 为未配置的字段填充默认值，启用加密时检查主密钥和密钥地址
+func validateEncryption(e *EncryptionConfig) error {
+	if e.PlaybackTokenTTLSeconds == 0 {
+		e.PlaybackTokenTTLSeconds = 300
+	}
+	if e.PlaybackTokenTTLSeconds < 0 {
+		return fmt.Errorf("encryption.playback_token_ttl_seconds must be positive")
+	}
+	e.KeyURLBase = strings.TrimRight(e.KeyURLBase, "/")
+	if !e.Enabled {
+		return nil
+	}
+	if _, err := e.MasterKeyBytes(); err != nil {
+		return err
+	}
+	// 播放列表和分片由 MinIO 提供，密钥地址必须是指向 API 的绝对地址
+	u, err := url.Parse(e.KeyURLBase)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("encryption.key_url_base must be an absolute http(s) URL, got %q", e.KeyURLBase)
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+// internal/config/profile.go
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Profile 定义了一个转码配置 (码率阶梯中的一档)
+type Profile struct {
+	Name string `mapstructure:"name"`
+	// Height 为目标高度，宽度按原始比例计算；高于原始视频的档位不会转码 (不放大)
+	Height int `mapstructure:"height"`
+	// Resolution 为旧版配置的 ffmpeg scale 参数 (例如 "-2:720")，未配置 height 时从中解析高度
+	Resolution string `mapstructure:"resolution"`
+
+	VideoCodec   string `mapstructure:"video_codec"`   // libx264 (默认) 或 libx265
+	Preset       string `mapstructure:"preset"`        // 编码器预设，默认 veryfast
+	VideoBitrate string `mapstructure:"video_bitrate"` // 目标码率，例如 "2800k"；为空时使用编码器默认的 CRF 模式
+	MaxRate      string `mapstructure:"maxrate"`       // 峰值码率，需同时配置 bufsize
+	BufSize      string `mapstructure:"bufsize"`
+	AudioCodec   string `mapstructure:"audio_codec"`   // aac (默认) 或 libopus
+	AudioBitrate string `mapstructure:"audio_bitrate"` // 默认 128k
+
+	// GOPSeconds 为关键帧间隔 (秒)，SegmentSeconds 为 HLS/DASH 分片时长 (秒)，分片时长必须是关键帧间隔的整数倍
+	GOPSeconds     int `mapstructure:"gop_seconds"`
+	SegmentSeconds int `mapstructure:"segment_seconds"`
+	// MaxFrameRate 为帧率上限，原始视频帧率更高时降帧，0 表示保持原帧率
+	MaxFrameRate float64 `mapstructure:"max_frame_rate"`
+}
+
+// AudioProfile 定义纯音频上传的一个 AAC 码率档位
+type AudioProfile struct {
+	Name         string `mapstructure:"name"`
+	AudioBitrate string `mapstructure:"audio_bitrate"` // 例如 "128k"
+	// SampleRate 为输出采样率，0 表示保持原采样率
+	SampleRate     int `mapstructure:"sample_rate"`
+	SegmentSeconds int `mapstructure:"segment_seconds"` // 默认 6
+}
+
+// WaveformConfig 定义纯音频波形数据的精度
+type WaveformConfig struct {
+	// Points 为波形的点数 (每个点一对最小值 / 最大值)，与时长无关，默认 2000
+	Points int `mapstructure:"points"`
+}
+
+// LoudnessConfig 定义 EBU R128 响度标准化 (ffmpeg loudnorm 两遍处理) 的目标
+type LoudnessConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// IntegratedLUFS 为目标综合响度，默认 -23 (EBU R128)；流媒体平台常用 -16 或 -14
+	IntegratedLUFS float64 `mapstructure:"integrated_lufs"`
+	// TruePeakDBTP 为真峰值上限，默认 -1
+	TruePeakDBTP float64 `mapstructure:"true_peak_dbtp"`
+	// LoudnessRange 为目标响度范围 (LU)，默认 11
+	LoudnessRange float64 `mapstructure:"loudness_range"`
+}
+
+// ThumbnailConfig 定义进度条预览图 (雪碧图 + WebVTT) 的生成参数
+type ThumbnailConfig struct {
+	// IntervalSeconds 为截图间隔，0 表示不生成
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+	// Width 为单张缩略图宽度，高度按视频比例计算
+	Width int `mapstructure:"width"`
+	// Columns x Rows 张缩略图拼成一张雪碧图
+	Columns int `mapstructure:"columns"`
+	Rows    int `mapstructure:"rows"`
+}
+
+// CoverConfig 定义封面的生成和上传参数
+type CoverConfig struct {
+	// 转码时在视频中均匀截取 Candidates 张候选封面 (每张取附近最有代表性的一帧)，
+	// 平均亮度 (0-255) 低于 BlackThreshold 的黑帧会被丢弃
+	Candidates     int     `mapstructure:"candidates"`
+	BlackThreshold float64 `mapstructure:"black_threshold"`
+	// 候选封面和自定义封面都缩放到 MaxWidth x MaxHeight 以内 (不放大)，统一编码为 JPEG
+	MaxWidth    int `mapstructure:"max_width"`
+	MaxHeight   int `mapstructure:"max_height"`
+	JPEGQuality int `mapstructure:"jpeg_quality"`
+	// MaxUploadSizeMB 为自定义封面图片的大小上限
+	MaxUploadSizeMB int64 `mapstructure:"max_upload_size_mb"`
+}
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	bitratePattern     = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[kKmM]?$`)
+	videoCodecs        = map[string]bool{"libx264": true, "libx265": true}
+	audioCodecs        = map[string]bool{"aac": true, "libopus": true}
+	// x264 和 x265 支持相同的预设名
+	encoderPresets = map[string]bool{
+		"ultrafast": true, "superfast": true, "veryfast": true, "faster": true, "fast": true,
+		"medium": true, "slow": true, "slower": true, "veryslow": true, "placebo": true,
+	}
+)
+
+// validateProfiles 为未配置的字段填充默认值并检查转码配置，返回第一个错误
+func validateProfiles(profiles []Profile) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("ffmpeg.profiles must not be empty")
+	}
+	names := make(map[string]bool)
+	for i := range profiles {
+		p := &profiles[i]
+		if !profileNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("ffmpeg.profiles[%d]: invalid name %q (letters, digits, '-' and '_' only)", i, p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("ffmpeg.profiles[%d]: duplicate name %q", i, p.Name)
+		}
+		names[p.Name] = true
+		if err := p.applyDefaults(); err != nil {
+			return fmt.Errorf("ffmpeg.profiles[%d] (%s): %w", i, p.Name, err)
+		}
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("ffmpeg.profiles[%d] (%s): %w", i, p.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Profile) applyDefaults() error {
+	if p.Height == 0 && p.Resolution != "" {
+		// 兼容旧配置 resolution: "-2:720"
+		_, h, ok := strings.Cut(p.Resolution, ":")
+		height, err := strconv.Atoi(h)
+		if !ok || err != nil {
+			return fmt.Errorf("cannot parse height from resolution %q, use height instead", p.Resolution)
+		}
+		p.Height = height
+	}
+	if p.VideoCodec == "" {
+		p.VideoCodec = "libx264"
+	}
+	if p.Preset == "" {
+		p.Preset = "veryfast"
+	}
+	if p.AudioCodec == "" {
+		p.AudioCodec = "aac"
+	}
+	if p.AudioBitrate == "" {
+		p.AudioBitrate = "128k"
+	}
+	if p.SegmentSeconds == 0 {
+		p.SegmentSeconds = 6
+	}
+	if p.GOPSeconds == 0 {
+		p.GOPSeconds = p.SegmentSeconds
+	}
+	return nil
+}
+
+func (p *Profile) validate() error {
+	// yuv420p 要求宽高为偶数
+	if p.Height <= 0 || p.Height%2 != 0 {
+		return fmt.Errorf("height must be a positive even number, got %d", p.Height)
+	}
+	if !videoCodecs[p.VideoCodec] {
+		return fmt.Errorf("unsupported video_codec %q", p.VideoCodec)
+	}
+	if !audioCodecs[p.AudioCodec] {
+		return fmt.Errorf("unsupported audio_codec %q", p.AudioCodec)
+	}
+	if !encoderPresets[p.Preset] {
+		return fmt.Errorf("unknown preset %q", p.Preset)
+	}
+	for field, value := range map[string]string{
+		"video_bitrate": p.VideoBitrate, "maxrate": p.MaxRate, "bufsize": p.BufSize, "audio_bitrate": p.AudioBitrate,
+	} {
+		if value != "" && !bitratePattern.MatchString(value) {
+			return fmt.Errorf("invalid %s %q", field, value)
+		}
+	}
+	if (p.MaxRate == "") != (p.BufSize == "") {
+		return fmt.Errorf("maxrate and bufsize must be set together")
+	}
+	if p.GOPSeconds <= 0 || p.SegmentSeconds <= 0 {
+		return fmt.Errorf("gop_seconds and segment_seconds must be positive")
+	}
+	// 每个分片都必须以关键帧开头
+	if p.SegmentSeconds%p.GOPSeconds != 0 {
+		return fmt.Errorf("segment_seconds (%d) must be a multiple of gop_seconds (%d)", p.SegmentSeconds, p.GOPSeconds)
+	}
+	if p.MaxFrameRate < 0 {
+		return fmt.Errorf("max_frame_rate must not be negative")
+	}
+	return nil
+}
+
+// validateAudioProfiles 为未配置的字段填充默认值并检查纯音频档位，档位名不能与视频档位重复
+func validateAudioProfiles(profiles []AudioProfile, videoProfiles []Profile, waveform *WaveformConfig) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("ffmpeg.audio_profiles must not be empty")
+	}
+	names := make(map[string]bool)
+	for _, p := range videoProfiles {
+		names[p.Name] = true
+	}
+	for i := range profiles {
+		p := &profiles[i]
+		if !profileNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("ffmpeg.audio_profiles[%d]: invalid name %q (letters, digits, '-' and '_' only)", i, p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("ffmpeg.audio_profiles[%d]: duplicate name %q", i, p.Name)
+		}
+		names[p.Name] = true
+		if p.AudioBitrate == "" || !bitratePattern.MatchString(p.AudioBitrate) {
+			return fmt.Errorf("ffmpeg.audio_profiles[%d] (%s): invalid audio_bitrate %q", i, p.Name, p.AudioBitrate)
+		}
+		if p.SampleRate < 0 {
+			return fmt.Errorf("ffmpeg.audio_profiles[%d] (%s): sample_rate must not be negative", i, p.Name)
+		}
+		if p.SegmentSeconds == 0 {
+			p.SegmentSeconds = 6
+		}
+		if p.SegmentSeconds < 0 {
+			return fmt.Errorf("ffmpeg.audio_profiles[%d] (%s): segment_seconds must be positive", i, p.Name)
+		}
+	}
+
+	if waveform.Points == 0 {
+		waveform.Points = 2000
+	}
+	if waveform.Points < 0 {
+		return fmt.Errorf("ffmpeg.waveform.points must be positive")
+	}
+	return nil
+}
+
+// validateLoudness 为未配置的字段填充默认值，并按 loudnorm 滤镜允许的范围检查
+func validateLoudness(l *LoudnessConfig) error {
+	if l.IntegratedLUFS == 0 {
+		l.IntegratedLUFS = -23
+	}
+	if l.TruePeakDBTP == 0 {
+		l.TruePeakDBTP = -1
+	}
+	if l.LoudnessRange == 0 {
+		l.LoudnessRange = 11
+	}
+	if l.IntegratedLUFS < -70 || l.IntegratedLUFS > -5 {
+		return fmt.Errorf("ffmpeg.loudness.integrated_lufs must be between -70 and -5, got %g", l.IntegratedLUFS)
+	}
+	if l.TruePeakDBTP < -9 || l.TruePeakDBTP > 0 {
+		return fmt.Errorf("ffmpeg.loudness.true_peak_dbtp must be between -9 and 0, got %g", l.TruePeakDBTP)
+	}
+	if l.LoudnessRange < 1 || l.LoudnessRange > 20 {
+		return fmt.Errorf("ffmpeg.loudness.loudness_range must be between 1 and 20, got %g", l.LoudnessRange)
+	}
+	return nil
+}
+
+// validateThumbnails 检查预览图配置，未启用 (interval_seconds 为 0) 时不检查
+func validateThumbnails(t *ThumbnailConfig) error {
+	if t.IntervalSeconds == 0 {
+		return nil
+	}
+	if t.IntervalSeconds < 0 {
+		return fmt.Errorf("ffmpeg.thumbnails.interval_seconds must not be negative")
+	}
+	if t.Width <= 0 || t.Width%2 != 0 {
+		return fmt.Errorf("ffmpeg.thumbnails.width must be a positive even number, got %d", t.Width)
+	}
+	if t.Columns <= 0 || t.Rows <= 0 {
+		return fmt.Errorf("ffmpeg.thumbnails.columns and rows must be positive")
+	}
+	return nil
+}
+
+// validateCover 为未配置的字段填充默认值并检查封面配置
+func validateCover(c *CoverConfig) error {
+	if c.Candidates == 0 {
+		c.Candidates = 5
+	}
+	if c.BlackThreshold == 0 {
+		c.BlackThreshold = 24
+	}
+	if c.MaxWidth == 0 {
+		c.MaxWidth = 1280
+	}
+	if c.MaxHeight == 0 {
+		c.MaxHeight = 1280
+	}
+	if c.JPEGQuality == 0 {
+		c.JPEGQuality = 85
+	}
+	if c.MaxUploadSizeMB == 0 {
+		c.MaxUploadSizeMB = 10
+	}
+
+	if c.Candidates < 1 || c.Candidates > 20 {
+		return fmt.Errorf("cover.candidates must be between 1 and 20, got %d", c.Candidates)
+	}
+	if c.BlackThreshold < 0 || c.BlackThreshold > 255 {
+		return fmt.Errorf("cover.black_threshold must be between 0 and 255")
+	}
+	if c.MaxWidth < 16 || c.MaxHeight < 16 {
+		return fmt.Errorf("cover.max_width and max_height must be at least 16")
+	}
+	if c.JPEGQuality < 1 || c.JPEGQuality > 100 {
+		return fmt.Errorf("cover.jpeg_quality must be between 1 and 100")
+	}
+	if c.MaxUploadSizeMB < 0 {
+		return fmt.Errorf("cover.max_upload_size_mb must not be negative")
+	}
+	return nil
+}
+
+// WatermarkConfig 定义平台级的水印 (频道 Logo)，单个上传者可在 user_watermarks 表中覆盖图片和样式
+type WatermarkConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Image 为水印图片 (建议为带透明通道的 PNG) 在 MinIO 桶中的对象路径
+	Image string `mapstructure:"image"`
+	// Position 为 top-left / top-right / bottom-left / bottom-right / center，默认 bottom-right
+	Position string `mapstructure:"position"`
+	// Margin 为水印到画面边缘的距离，相对输出高度的比例，默认 0.03
+	Margin float64 `mapstructure:"margin"`
+	// Opacity 为不透明度 (0, 1]，默认 0.8
+	Opacity float64 `mapstructure:"opacity"`
+	// Scale 为水印高度相对输出高度的比例，默认 0.08
+	Scale float64 `mapstructure:"scale"`
+	// OptOutRoles 中的角色可以为自己上传的视频关闭水印
+	OptOutRoles []string `mapstructure:"opt_out_roles"`
+}
+
+// WatermarkPositions 是水印支持的位置
+var WatermarkPositions = []string{"top-left", "top-right", "bottom-left", "bottom-right", "center"}
+
+// validateWatermark 为未配置的字段填充默认值并检查水印配置，未启用时也检查，便于个人覆盖沿用默认样式
+func validateWatermark(w *WatermarkConfig) error {
+	if w.Position == "" {
+		w.Position = "bottom-right"
+	}
+	if w.Margin == 0 {
+		w.Margin = 0.03
+	}
+	if w.Opacity == 0 {
+		w.Opacity = 0.8
+	}
+	if w.Scale == 0 {
+		w.Scale = 0.08
+	}
+	if w.Enabled && w.Image == "" {
+		return fmt.Errorf("watermark.image is required when the watermark is enabled")
+	}
+	return ValidateWatermarkStyle(w.Position, w.Margin, w.Opacity, w.Scale)
+}
+
+// ValidateWatermarkStyle 检查水印的位置和比例，也用于检查 user_watermarks 中的个人覆盖
+func ValidateWatermarkStyle(position string, margin, opacity, scale float64) error {
+	if !slices.Contains(WatermarkPositions, position) {
+		return fmt.Errorf("invalid watermark position %q, expected one of %s", position, strings.Join(WatermarkPositions, ", "))
+	}
+	if margin < 0 || margin > 0.5 {
+		return fmt.Errorf("watermark margin must be between 0 and 0.5, got %g", margin)
+	}
+	if opacity <= 0 || opacity > 1 {
+		return fmt.Errorf("watermark opacity must be in (0, 1], got %g", opacity)
+	}
+	if scale <= 0 || scale > 1 {
+		return fmt.Errorf("watermark scale must be in (0, 1], got %g", scale)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+// internal/config/worker.go
+package config
+
+import "fmt"
+
+// WorkerConfig 定义 Worker 的并发和退出行为
+type WorkerConfig struct {
+	// Concurrency 为每个队列同时处理的任务数，同时作为 RabbitMQ 的预取数量 (prefetch)，默认 1
+	Concurrency int `mapstructure:"concurrency"`
+	// ShutdownTimeoutSeconds 收到退出信号后等待正在执行的任务完成的最长时间，超时后中止任务并退回队列，默认 300
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
+}
+
+// validateWorker 为未配置的字段填充默认值。超时为 0 时 Worker 一收到退出信号就会中止所有任务
+func validateWorker(w *WorkerConfig) error {
+	if w.Concurrency == 0 {
+		w.Concurrency = 1
+	}
+	if w.Concurrency < 0 {
+		return fmt.Errorf("worker.concurrency must be positive")
+	}
+	if w.ShutdownTimeoutSeconds == 0 {
+		w.ShutdownTimeoutSeconds = 300
+	}
+	if w.ShutdownTimeoutSeconds < 0 {
+		return fmt.Errorf("worker.shutdown_timeout_seconds must be positive")
+	}
+	return nil
+}
@@ -16,6 +16,8 @@ import (
 var (
 	DB          *gorm.DB
 	MinioClient *minio.Client
+	// MinioCore 暴露底层的分片上传 API (NewMultipartUpload / ListObjectParts 等)
+	MinioCore *minio.Core
 )
 
 // InitMySQL 初始化数据库连接 (V2版，兼容新配置)
@@ -48,6 +50,7 @@ func InitMinIO(cfg *config.Config) {
 	if err != nil {
 		log.Fatalf("Failed to initialize minio client: %v", err)
 	}
+	MinioCore = &minio.Core{Client: MinioClient}
 
 	// 检查存储桶是否存在，如果不存在则创建
 	bucketName := cfg.MinIO.BucketName
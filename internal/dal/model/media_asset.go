@@ -0,0 +1,21 @@
+// internal/dal/model/media_asset.go
+package model
+
+import "time"
+
+// MediaAsset 对应数据库中的 'media_assets' 表，表示 processed/<id>/ 下的一套转码产物。
+// 内容相同 (ContentHash 和 WatermarkKey 一致) 的视频共享同一个 MediaAsset，RefCount 为引用它的视频数；加密的产物不共享。
+type MediaAsset struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement"   json:"id"`
+	ContentHash   string    `gorm:"type:varchar(64);not null;index" json:"content_hash"`
+	WatermarkKey  string    `gorm:"type:varchar(64);not null;default:''" json:"watermark_key"` // 叠加的水印的指纹，没有水印时为空
+	Encrypted     bool      `gorm:"not null;default:false"     json:"encrypted"`               // HLS 使用视频自己的内容密钥加密，不能共享
+	StoragePrefix string    `gorm:"type:varchar(255);not null" json:"storage_prefix"`
+	RefCount      uint      `gorm:"not null;default:1"         json:"ref_count"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"             json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"             json:"updated_at"`
+}
+
+func (MediaAsset) TableName() string {
+	return "media_assets"
+}
@@ -0,0 +1,19 @@
+// internal/dal/model/quota.go
+package model
+
+import "time"
+
+// UserQuota 对应 'user_quotas' 表，用于覆盖某个用户的角色默认配额。
+// 字段为 NULL 时沿用角色默认值，为 0 时表示不限制。
+type UserQuota struct {
+	UserID             uint64    `gorm:"primaryKey"     json:"user_id"`
+	MaxStorageBytes    *uint64   `json:"max_storage_bytes"`
+	MaxVideosPerDay    *int      `json:"max_videos_per_day"`
+	MaxDurationSeconds *int      `json:"max_duration_seconds"`
+	CreatedAt          time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (UserQuota) TableName() string {
+	return "user_quotas"
+}
@@ -0,0 +1,24 @@
+// internal/dal/model/upload_session.go
+package model
+
+import "time"
+
+// UploadSession 对应数据库中的 'upload_sessions' 表，记录一次 MinIO 分片上传会话
+type UploadSession struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"  json:"id"`
+	VideoID   uint64 `gorm:"not null;uniqueIndex"      json:"video_id"`
+	UploadID  string `gorm:"type:varchar(255);not null" json:"upload_id"` // MinIO 返回的 uploadId
+	ObjectKey string `gorm:"type:varchar(1024);not null" json:"object_key"`
+	PartSize  uint64 `gorm:"not null"                  json:"part_size"`
+	FileSize  uint64 `json:"file_size"` // 客户端声明的文件总大小，可能为 0 (未知)
+	Protocol  string `gorm:"type:enum('multipart','tus');default:'multipart'" json:"protocol"`
+	// ReceivedBytes 仅 tus 上传使用: 服务端已持久化的字节数，即 tus 协议中的 Upload-Offset
+	ReceivedBytes uint64    `gorm:"not null;default:0" json:"received_bytes"`
+	Status        string    `gorm:"type:enum('active','completed','aborted');default:'active'" json:"status"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"            json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"            json:"updated_at"`
+}
+
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
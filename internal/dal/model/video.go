@@ -1,7 +1,10 @@
 // internal/dal/model/video.go
 package model
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // Video 模型定义
 type Video struct {
@@ -9,11 +12,28 @@ type Video struct {
 	UserID           uint64    `gorm:"not null"                 json:"user_id"`
 	Title            string    `gorm:"type:varchar(255);not null" json:"title"`
 	Description      string    `gorm:"type:text"                json:"description"`
-	// 新增字段，用于存储原始上传的文件名
+	// 新增字段，用于存储原始上传的文件名 (已清理，仅用于展示，不参与构造任何路径)
 	OriginalFileName string    `gorm:"type:varchar(255);not null" json:"original_file_name"`
-	Status           string    `gorm:"type:enum('uploading','transcoding','online','failed','private');default:'uploading'" json:"status"`
+	// ObjectKey 是原始文件在 MinIO 中的对象路径，由服务端生成，例如 raw/123/9f86d081884c7d65.mp4
+	ObjectKey        string    `gorm:"type:varchar(255);not null;default:''" json:"-"`
+	// RawSize 是原始文件的字节数，完成上传时记录，配额统计直接求和，不需要逐个列出 MinIO 对象
+	RawSize          uint64    `gorm:"not null;default:0"       json:"-"`
+	Status           string    `gorm:"type:enum('uploading','transcoding','online','failed','dead_lettered','private');default:'uploading'" json:"status"`
 	Duration         uint      `json:"duration"`
+	// TranscodeAttempts 是转码任务已经失败的次数，Worker 开始转码时写入消息中的计数，
+	// reaper 重新提交卡住的转码时据此继续计数
+	TranscodeAttempts int      `gorm:"not null;default:0"       json:"-"`
 	CoverURL         string    `gorm:"type:varchar(1024)"       json:"cover_url"`
+	// ThumbnailVTTURL 是进度条预览图的 WebVTT 文件 (processed/<id>/thumbs/thumbnails.vtt)，详情接口返回签名 URL
+	ThumbnailVTTURL  string    `gorm:"column:thumbnail_vtt_url;type:varchar(1024)" json:"thumbnail_vtt_url"`
+	// MediaType 由转码时 ffprobe 的结果决定: 没有视频流 (内嵌专辑封面不算) 的上传为 audio
+	MediaType        string    `gorm:"type:enum('video','audio');not null;default:'video'" json:"media_type"`
+	// WaveformURL 是纯音频的波形数据 (processed/<id>/waveform.json)，详情接口返回签名 URL
+	WaveformURL      string    `gorm:"column:waveform_url;type:varchar(1024)" json:"waveform_url"`
+	// ContentHash 是原始文件的 SHA-256，用于识别重复上传
+	ContentHash      string    `gorm:"type:varchar(64);index"   json:"-"`
+	// AssetID 指向该视频使用的转码产物 (media_assets)，多个内容相同的视频共享同一份
+	AssetID          *uint64   `json:"-"`
 	CreatedAt        time.Time `gorm:"autoCreateTime"           json:"created_at"`
 	UpdatedAt        time.Time `gorm:"autoUpdateTime"           json:"updated_at"`
 }
@@ -22,14 +42,48 @@ func (Video) TableName() string {
 	return "videos"
 }
 
-// VideoSource 模型定义保持不变
+// RawObjectKey 返回原始上传文件在 MinIO 中的对象路径，例如 raw/123/9f86d081884c7d65.mp4。
+// 旧版本创建、还没有运行 cmd/migrate-keys 的视频返回空字符串，调用方不能据此读取或删除对象
+func (v *Video) RawObjectKey() string {
+	return v.ObjectKey
+}
+
+// IDString 返回十进制的视频 ID，用于构造对象路径
+func (v *Video) IDString() string {
+	return strconv.FormatUint(v.ID, 10)
+}
+
+// 媒体类型
+const (
+	MediaTypeVideo = "video"
+	MediaTypeAudio = "audio"
+)
+
+// 播放源格式
+const (
+	SourceFormatHLS  = "HLS"
+	SourceFormatDASH = "DASH"
+)
+
+// SourceQualityAuto 是自适应码率主播放列表 (processed/<id>/master.m3u8) 对应的清晰度
+const SourceQualityAuto = "auto"
+
+// VideoSource 模型定义，每个清晰度每种格式一条
 type VideoSource struct {
 	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
-	VideoID   uint64    `gorm:"not null;uniqueIndex:uk_video_quality" json:"video_id"`
-	Quality   string    `gorm:"type:varchar(20);not null;uniqueIndex:uk_video_quality" json:"quality"`
-	Format    string    `gorm:"type:varchar(20);not null" json:"format"`
+	VideoID   uint64    `gorm:"not null;uniqueIndex:uk_video_quality_format" json:"video_id"`
+	Quality   string    `gorm:"type:varchar(20);not null;uniqueIndex:uk_video_quality_format" json:"quality"`
+	Format    string    `gorm:"type:varchar(20);not null;uniqueIndex:uk_video_quality_format" json:"format"`
 	URL       string    `gorm:"type:varchar(1024);not null" json:"url"`
 	FileSize  uint64    `json:"file_size"`
+	// 以下为该档位的码流属性，与 HLS 主播放列表中的 BANDWIDTH / RESOLUTION / CODECS / FRAME-RATE 一致
+	Bandwidth uint64    `json:"bandwidth"` // 峰值码率 (bit/s)
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Codecs    string    `gorm:"type:varchar(100)" json:"codecs"`
+	FrameRate float64   `gorm:"type:decimal(6,3)" json:"frame_rate"`
+	// WatermarkKey 是转码该档位时叠加的水印的指纹，没有水印时为空；重试时水印变了的检查点不能复用
+	WatermarkKey string `gorm:"type:varchar(64);not null;default:''" json:"-"`
 	CreatedAt time.Time `gorm:"autoCreateTime"            json:"created_at"`
 }
 
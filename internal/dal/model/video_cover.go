@@ -0,0 +1,19 @@
+// internal/dal/model/video_cover.go
+package model
+
+import "time"
+
+// VideoCoverCandidate 对应数据库中的 'video_cover_candidates' 表，是转码时截取的候选封面 (已过滤黑帧)。
+// 所有者可以从中选择一张作为 videos.cover_url。
+type VideoCoverCandidate struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement"    json:"id"`
+	VideoID    uint64    `gorm:"not null;index"              json:"video_id"`
+	URL        string    `gorm:"type:varchar(1024);not null" json:"url"`        // 对象路径，例如 processed/1/covers/candidate-1.jpg
+	Offset     float64   `gorm:"type:decimal(10,3)"          json:"offset"`     // 截取位置 (秒)
+	Brightness float64   `gorm:"type:decimal(6,2)"           json:"brightness"` // 平均亮度 0-255
+	CreatedAt  time.Time `gorm:"autoCreateTime"              json:"created_at"`
+}
+
+func (VideoCoverCandidate) TableName() string {
+	return "video_cover_candidates"
+}
@@ -0,0 +1,21 @@
+// internal/dal/model/video_import.go
+package model
+
+import "time"
+
+// VideoImport 对应数据库中的 'video_imports' 表，记录一次远程 URL 导入任务及其进度
+type VideoImport struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement"   json:"id"`
+	VideoID       uint64    `gorm:"not null;uniqueIndex"       json:"video_id"`
+	SourceURL     string    `gorm:"type:varchar(2048);not null" json:"source_url"`
+	Status        string    `gorm:"type:enum('pending','downloading','completed','failed');default:'pending'" json:"status"`
+	TotalBytes    uint64    `gorm:"not null;default:0"         json:"total_bytes"` // 0 表示源站没有返回 Content-Length
+	ReceivedBytes uint64    `gorm:"not null;default:0"         json:"received_bytes"`
+	Error         string    `gorm:"type:varchar(1024)"         json:"error,omitempty"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"             json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"             json:"updated_at"`
+}
+
+func (VideoImport) TableName() string {
+	return "video_imports"
+}
@@ -0,0 +1,16 @@
+// internal/dal/model/video_key.go
+package model
+
+import "time"
+
+// VideoKey 对应数据库中的 'video_keys' 表，是视频 HLS 加密使用的 AES-128 内容密钥，每个视频一个。
+// EncryptedKey 为用 encryption.master_key (AES-256-GCM) 加密后的密钥: nonce || 密文
+type VideoKey struct {
+	VideoID      uint64    `gorm:"primaryKey;autoIncrement:false"    json:"-"`
+	EncryptedKey []byte    `gorm:"type:varbinary(64);not null"       json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"                    json:"created_at"`
+}
+
+func (VideoKey) TableName() string {
+	return "video_keys"
+}
@@ -0,0 +1,25 @@
+// internal/dal/model/video_loudness.go
+package model
+
+import "time"
+
+// ReplayGainReferenceLUFS 是 ReplayGain 2.0 的参考响度
+const ReplayGainReferenceLUFS = -18.0
+
+// VideoLoudness 对应数据库中的 'video_loudness' 表，是转码时 loudnorm 第一遍测量的原始音频响度 (EBU R128)，
+// 每个视频一条。有记录说明播放源的音频已标准化到 TargetLUFS。
+type VideoLoudness struct {
+	VideoID        uint64  `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	IntegratedLUFS float64 `gorm:"type:decimal(6,2);not null"     json:"integrated_lufs"` // 原始音频的综合响度
+	TruePeakDBTP   float64 `gorm:"type:decimal(6,2);not null"     json:"true_peak_dbtp"`  // 原始音频的真峰值
+	LoudnessRange  float64 `gorm:"type:decimal(6,2);not null"     json:"loudness_range"`  // 原始音频的响度范围 (LU)
+	Threshold      float64 `gorm:"type:decimal(6,2);not null"     json:"threshold"`
+	TargetLUFS     float64 `gorm:"type:decimal(6,2);not null"     json:"target_lufs"` // 标准化目标
+	// ReplayGainDB 是播放源相对 ReplayGain 参考响度 (-18 LUFS) 需要的增益，播放器可以据此再统一调整音量
+	ReplayGainDB float64   `gorm:"type:decimal(6,2);not null" json:"replay_gain_db"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"             json:"created_at"`
+}
+
+func (VideoLoudness) TableName() string {
+	return "video_loudness"
+}
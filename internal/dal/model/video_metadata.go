@@ -0,0 +1,44 @@
+// internal/dal/model/video_metadata.go
+package model
+
+import "time"
+
+// VideoMetadata 对应数据库中的 'video_metadata' 表，保存 ffprobe 读到的原始文件元数据，每个视频一条。
+// Width / Height 为按旋转信息修正后的显示尺寸，转码档位据此选择。
+type VideoMetadata struct {
+	VideoID      uint64           `gorm:"primaryKey;autoIncrement:false" json:"video_id"`
+	Container    string           `gorm:"type:varchar(100);not null"     json:"container"`
+	Duration     float64          `gorm:"not null;default:0"             json:"duration"`    // 秒
+	BitRate      int64            `gorm:"not null;default:0"             json:"bit_rate"`    // 总码率 bit/s
+	VideoCodec   string           `gorm:"type:varchar(50);not null"      json:"video_codec"` // 第一个视频流
+	AudioCodec   string           `gorm:"type:varchar(50);not null"      json:"audio_codec"` // 第一个音频流
+	Width        int              `gorm:"not null;default:0"             json:"width"`
+	Height       int              `gorm:"not null;default:0"             json:"height"`
+	FrameRate    float64          `gorm:"type:decimal(8,3);not null;default:0" json:"frame_rate"`
+	Rotation     int              `gorm:"not null;default:0"             json:"rotation"`
+	CreationTime *time.Time       `                                      json:"creation_time,omitempty"`
+	Streams      []StreamMetadata `gorm:"type:json;serializer:json"      json:"streams"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime"                 json:"created_at"`
+	UpdatedAt    time.Time        `gorm:"autoUpdateTime"                 json:"updated_at"`
+}
+
+// StreamMetadata 是单个流的元数据，以 JSON 数组保存在 video_metadata.streams 中
+type StreamMetadata struct {
+	Index       int     `json:"index"`
+	Type        string  `json:"type"` // video / audio / subtitle / data
+	Codec       string  `json:"codec"`
+	Profile     string  `json:"profile,omitempty"`
+	Width       int     `json:"width,omitempty"`
+	Height      int     `json:"height,omitempty"`
+	FrameRate   float64 `json:"frame_rate,omitempty"`
+	BitRate     int64   `json:"bit_rate,omitempty"`
+	PixelFormat string  `json:"pixel_format,omitempty"`
+	Rotation    int     `json:"rotation,omitempty"`
+	Channels    int     `json:"channels,omitempty"`
+	SampleRate  int     `json:"sample_rate,omitempty"`
+	Language    string  `json:"language,omitempty"`
+}
+
+func (VideoMetadata) TableName() string {
+	return "video_metadata"
+}
@@ -0,0 +1,24 @@
+// internal/dal/model/video_subtitle.go
+package model
+
+import "time"
+
+// VideoSubtitle 对应数据库中的 'video_subtitles' 表，每个视频每种语言一条字幕轨道。
+// 上传的 SRT / WebVTT / ASS 文件统一转换为 WebVTT 保存在 subtitles/<video_id>/ 下。
+type VideoSubtitle struct {
+	ID           uint64    `gorm:"primaryKey;autoIncrement"                               json:"id"`
+	VideoID      uint64    `gorm:"not null;uniqueIndex:uk_video_language"                 json:"video_id"`
+	Language     string    `gorm:"type:varchar(35);not null;uniqueIndex:uk_video_language" json:"language"` // BCP 47，例如 en、zh-CN
+	Label        string    `gorm:"type:varchar(100);not null"                             json:"label"`     // 播放器中显示的名称
+	SourceFormat string    `gorm:"type:varchar(10);not null"                              json:"source_format"`
+	URL          string    `gorm:"type:varchar(1024);not null"                            json:"url"`          // WebVTT 对象路径
+	PlaylistURL  string    `gorm:"type:varchar(1024);not null"                            json:"playlist_url"` // HLS 字幕媒体播放列表
+	IsDefault    bool      `gorm:"not null;default:false"                                 json:"is_default"`
+	CueCount     int       `gorm:"not null;default:0"                                     json:"cue_count"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"                                         json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"                                         json:"updated_at"`
+}
+
+func (VideoSubtitle) TableName() string {
+	return "video_subtitles"
+}
@@ -0,0 +1,22 @@
+// internal/dal/model/watermark.go
+package model
+
+import "time"
+
+// UserWatermark 对应 'user_watermarks' 表，用于覆盖某个上传者的平台水印。
+// 图片和样式字段为 NULL 时沿用 watermark 配置；OptOut 为上传者自己关闭水印，角色在 watermark.opt_out_roles 中时才生效。
+type UserWatermark struct {
+	UserID    uint64    `gorm:"primaryKey"             json:"user_id"`
+	Image     *string   `gorm:"type:varchar(255)"      json:"image"`
+	Position  *string   `gorm:"type:varchar(20)"       json:"position"`
+	Margin    *float64  `json:"margin"`
+	Opacity   *float64  `json:"opacity"`
+	Scale     *float64  `json:"scale"`
+	OptOut    bool      `gorm:"not null;default:false" json:"opt_out"`
+	CreatedAt time.Time `gorm:"autoCreateTime"         json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"         json:"updated_at"`
+}
+
+func (UserWatermark) TableName() string {
+	return "user_watermarks"
+}
@@ -3,6 +3,7 @@ package dal
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"github.com/cjh/video-platform-go/internal/config"
 	"github.com/rabbitmq/amqp091-go"
@@ -39,9 +40,73 @@ func InitRabbitMQ(cfg *config.Config) {
 	if err != nil {
 		log.Fatalf("Failed to declare a queue: %v", err)
 	}
+
+	// 远程 URL 导入任务队列
+	if cfg.RabbitMQ.ImportQueue != "" {
+		if _, err = MQChan.QueueDeclare(cfg.RabbitMQ.ImportQueue, true, false, false, false, nil); err != nil {
+			log.Fatalf("Failed to declare the import queue: %v", err)
+		}
+	}
+
+	// 转码重试队列和死信队列
+	if err = declareTranscodeRetryQueues(cfg); err != nil {
+		log.Fatalf("Failed to declare the transcode retry queues: %v", err)
+	}
 	log.Println("RabbitMQ connection and queue declared")
 }
 
+// TranscodeRetryQueue 返回延迟 delaySeconds 秒的转码重试队列名
+func TranscodeRetryQueue(delaySeconds int) string {
+	return fmt.Sprintf("%s.retry.%ds", config.AppConfig.RabbitMQ.TranscodeQueue, delaySeconds)
+}
+
+// declareTranscodeRetryQueues 为每个重试延迟声明一个没有消费者的队列: 消息在其中等待 TTL 到期后，
+// 经默认 exchange 死信回转码队列，实现延迟重入队。另外声明重试耗尽后存放任务的死信队列。
+func declareTranscodeRetryQueues(cfg *config.Config) error {
+	for _, delay := range cfg.RabbitMQ.TranscodeRetryDelaysSeconds {
+		if delay <= 0 {
+			return fmt.Errorf("invalid transcode retry delay %d", delay)
+		}
+		if _, err := MQChan.QueueDeclare(TranscodeRetryQueue(delay), true, false, false, false, amqp091.Table{
+			"x-message-ttl":             int64(delay) * 1000,
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": cfg.RabbitMQ.TranscodeQueue,
+		}); err != nil {
+			return err
+		}
+	}
+	if cfg.RabbitMQ.TranscodeDeadLetterQueue != "" {
+		if _, err := MQChan.QueueDeclare(cfg.RabbitMQ.TranscodeDeadLetterQueue, true, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeclareUploadEventsQueue 声明 MinIO AMQP 通知目标使用的 exchange 和 Worker 消费的队列，并把两者绑定。
+// exchange 的参数 (direct、durable) 需要与 MinIO 的 MINIO_NOTIFY_AMQP_* 配置一致。
+func DeclareUploadEventsQueue(cfg *config.Config) error {
+	exchange := cfg.RabbitMQ.UploadEventsExchange
+	queue := cfg.RabbitMQ.UploadEventsQueue
+
+	if err := MQChan.ExchangeDeclare(
+		exchange, // name
+		"direct", // kind
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	); err != nil {
+		return err
+	}
+	if _, err := MQChan.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return err
+	}
+	// MinIO 使用 MINIO_NOTIFY_AMQP_ROUTING_KEY 作为路由键，这里约定与队列同名
+	return MQChan.QueueBind(queue, queue, exchange, false, nil)
+}
+
 // PublishTranscodeTask 发布转码任务到队列
 func PublishTranscodeTask(ctx context.Context, body []byte) error {
 	return MQChan.PublishWithContext(ctx,
@@ -54,4 +119,29 @@ func PublishTranscodeTask(ctx context.Context, body []byte) error {
 			Body:        body,
 		},
 	)
-}
\ No newline at end of file
+}
+
+// PublishTranscodeTaskTo 把转码任务 (带重试次数等 headers) 发布到指定队列，用于重试、死信和重新投递
+func PublishTranscodeTaskTo(ctx context.Context, queue string, body []byte, headers amqp091.Table) error {
+	return MQChan.PublishWithContext(ctx, "", queue, false, false, amqp091.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp091.Persistent,
+		Headers:      headers,
+		Body:         body,
+	})
+}
+
+// PublishImportTask 发布远程 URL 导入任务到队列
+func PublishImportTask(ctx context.Context, body []byte) error {
+	return MQChan.PublishWithContext(ctx,
+		"",                                    // exchange
+		config.AppConfig.RabbitMQ.ImportQueue, // routing key (queue name)
+		false,                                 // mandatory
+		false,                                 // immediate
+		amqp091.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp091.Persistent,
+			Body:         body,
+		},
+	)
+}
@@ -0,0 +1,26 @@
+// internal/dal/redis.go
+package dal
+
+import (
+	"context"
+	"log"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 用于保存转码进度等短期状态，并通过 Pub/Sub 推送给 API
+var Redis *redis.Client
+
+// InitRedis 初始化 Redis 客户端
+func InitRedis(cfg *config.Config) {
+	Redis = redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := Redis.Ping(context.Background()).Err(); err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+	log.Println("Redis connection established")
+}
@@ -0,0 +1,57 @@
+// internal/media/cover.go
+package media
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+// MeanLuma 返回图片的平均亮度 (0-255)，用于识别黑帧
+func MeanLuma(img image.Image) float64 {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0
+	}
+
+	var sum uint64
+	if ycc, ok := img.(*image.YCbCr); ok {
+		// ffmpeg 输出的 JPEG 解码后是 YCbCr，直接读取 Y 平面
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			row := ycc.Y[ycc.YOffset(b.Min.X, y) : ycc.YOffset(b.Max.X-1, y)+1]
+			for _, v := range row {
+				sum += uint64(v)
+			}
+		}
+	} else {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				sum += uint64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			}
+		}
+	}
+	return float64(sum) / float64(b.Dx()*b.Dy())
+}
+
+// ResizeToFit 把图片等比缩放到 maxWidth x maxHeight 以内 (不放大)，透明区域填充为白色，
+// 返回的图片从 (0, 0) 开始，可以直接编码为 JPEG
+func ResizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxWidth {
+		h, w = max(1, h*maxWidth/w), maxWidth
+	}
+	if h > maxHeight {
+		w, h = max(1, w*maxHeight/h), maxHeight
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	if w == b.Dx() && h == b.Dy() {
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	}
+	return dst
+}
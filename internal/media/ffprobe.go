@@ -0,0 +1,201 @@
+// internal/media/ffprobe.go
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProbeFormat 对应 ffprobe -show_format 输出中的 format 部分
+type ProbeFormat struct {
+	FormatName string `json:"format_name"`
+	Duration   string `json:"duration"`
+	Size       string `json:"size"`
+	BitRate    string `json:"bit_rate"`
+	// Tags 为容器级元数据，例如 creation_time
+	Tags map[string]string `json:"tags"`
+}
+
+// ProbeStream 对应 ffprobe -show_streams 输出中的单个流
+type ProbeStream struct {
+	Index     int    `json:"index"`
+	CodecType string `json:"codec_type"`
+	CodecName string `json:"codec_name"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	// 以下用于生成 HLS 的 CODECS / FRAME-RATE 属性
+	Profile      string `json:"profile"`
+	Level        int    `json:"level"`
+	AvgFrameRate string `json:"avg_frame_rate"` // 例如 "30000/1001"
+	RFrameRate   string `json:"r_frame_rate"`
+	// 以下用于保存原始视频的元数据 (video_metadata)
+	PixFmt       string            `json:"pix_fmt"`
+	BitRate      string            `json:"bit_rate"`
+	Channels     int               `json:"channels"`
+	SampleRate   string            `json:"sample_rate"`
+	Tags         map[string]string `json:"tags"` // 旧版 ffmpeg 的旋转信息在 tags.rotate 中
+	SideDataList []struct {
+		SideDataType string  `json:"side_data_type"`
+		Rotation     float64 `json:"rotation"` // Display Matrix 的旋转角度，逆时针为正
+	} `json:"side_data_list"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"` // MP3 / M4A 内嵌的专辑封面以视频流的形式出现，值为 1
+	} `json:"disposition"`
+}
+
+// ProbeResult 是 ffprobe 的 JSON 输出
+type ProbeResult struct {
+	Format  ProbeFormat   `json:"format"`
+	Streams []ProbeStream `json:"streams"`
+}
+
+// ProbeError 表示 ffprobe 正常运行但以非零状态退出，Stderr 为它的错误输出
+type ProbeError struct {
+	Stderr string
+}
+
+func (e *ProbeError) Error() string {
+	return "ffprobe failed: " + e.Stderr
+}
+
+// probeNetworkErrors 是 ffprobe 读取 HTTP(S) 输入时网络问题的错误输出，出现时文件本身不一定有问题
+var probeNetworkErrors = []string{
+	"Connection refused",
+	"Connection timed out",
+	"Connection reset",
+	"Network is unreachable",
+	"Failed to resolve",
+	"Name or service not known",
+	"Server returned 5",
+	"I/O error",
+}
+
+// NetworkError 判断 ffprobe 的失败是否由读取输入时的网络问题引起 (而不是输入不是有效的媒体文件)
+func (e *ProbeError) NetworkError() bool {
+	for _, s := range probeNetworkErrors {
+		if strings.Contains(e.Stderr, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Probe 调用 ffprobe 读取媒体信息，input 可以是本地路径，也可以是 HTTP(S) 地址 (例如 MinIO 预签名 URL)。
+// ffprobe 运行后报错时返回 *ProbeError；ctx 超时或被取消时返回的错误包装 ctx.Err()
+func Probe(ctx context.Context, input string) (*ProbeResult, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		input,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		// 超时被杀死的 ffprobe 同样返回 ExitError，需要先检查 ctx
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("ffprobe interrupted: %w", ctx.Err())
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, &ProbeError{Stderr: string(exitErr.Stderr)}
+		}
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var result ProbeResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return &result, nil
+}
+
+// DurationSeconds 返回媒体时长 (秒)，无法解析时返回 0
+func (p *ProbeResult) DurationSeconds() float64 {
+	d, _ := strconv.ParseFloat(p.Format.Duration, 64)
+	return d
+}
+
+// FirstStream 返回第一个指定类型的流，没有时返回 nil
+func (p *ProbeResult) FirstStream(codecType string) *ProbeStream {
+	for i := range p.Streams {
+		if p.Streams[i].CodecType == codecType {
+			return &p.Streams[i]
+		}
+	}
+	return nil
+}
+
+// PrimaryVideoStream 返回第一个真正的视频流，跳过内嵌的封面图片，没有时返回 nil
+func (p *ProbeResult) PrimaryVideoStream() *ProbeStream {
+	for i := range p.Streams {
+		if p.Streams[i].CodecType == "video" && p.Streams[i].Disposition.AttachedPic == 0 {
+			return &p.Streams[i]
+		}
+	}
+	return nil
+}
+
+// IsAudioOnly 判断是否为纯音频文件: 有音频流，且除内嵌封面外没有视频流
+func (p *ProbeResult) IsAudioOnly() bool {
+	return p.PrimaryVideoStream() == nil && p.HasStream("audio")
+}
+
+// FrameRate 把 ffprobe 的分数形式帧率 (avg_frame_rate，缺失时用 r_frame_rate) 转为小数，无法解析时返回 0
+func (s *ProbeStream) FrameRate() float64 {
+	for _, rate := range []string{s.AvgFrameRate, s.RFrameRate} {
+		num, den, ok := strings.Cut(rate, "/")
+		if !ok {
+			continue
+		}
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 == nil && err2 == nil && n > 0 && d > 0 {
+			return n / d
+		}
+	}
+	return 0
+}
+
+// HasStream 判断是否包含指定类型 (video / audio / subtitle) 的流
+func (p *ProbeResult) HasStream(codecType string) bool {
+	for _, s := range p.Streams {
+		if s.CodecType == codecType {
+			return true
+		}
+	}
+	return false
+}
+
+// CreationTime 返回容器元数据中的拍摄/创建时间，没有或无法解析时返回零值
+func (p *ProbeResult) CreationTime() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, p.Format.Tags["creation_time"])
+	return t
+}
+
+// Rotation 返回播放时需要顺时针旋转的角度 (0 / 90 / 180 / 270)
+func (s *ProbeStream) Rotation() int {
+	degrees := 0
+	if r, err := strconv.Atoi(s.Tags["rotate"]); err == nil {
+		degrees = r
+	}
+	for _, sd := range s.SideDataList {
+		if sd.SideDataType == "Display Matrix" {
+			degrees = -int(math.Round(sd.Rotation))
+		}
+	}
+	return ((degrees % 360) + 360) % 360
+}
+
+// DisplaySize 返回按旋转信息修正后的宽高，即播放器实际显示的尺寸。
+// ffmpeg 转码时默认自动旋转，所以转码档位要按这个高度选择。
+func (s *ProbeStream) DisplaySize() (width, height int) {
+	if r := s.Rotation(); r == 90 || r == 270 {
+		return s.Height, s.Width
+	}
+	return s.Width, s.Height
+}
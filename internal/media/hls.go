@@ -0,0 +1,218 @@
+// internal/media/hls.go
+package media
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HLSVariant 是主播放列表中的一个码率档位
+type HLSVariant struct {
+	URI              string  // 视频媒体播放列表，相对主播放列表的路径
+	AudioURI         string  // 对应的音频播放列表，为空表示没有单独的音轨
+	Bandwidth        uint64  // 峰值码率 (bit/s)，包含音频
+	AverageBandwidth uint64  // 平均码率 (bit/s)，包含音频
+	Width            int     // 分辨率
+	Height           int     // 分辨率
+	Codecs           string  // RFC 6381 编码字符串，例如 "avc1.64001f,mp4a.40.2"
+	FrameRate        float64 // 帧率，0 表示未知
+}
+
+// BuildMasterPlaylist 生成带 BANDWIDTH / RESOLUTION / CODECS / FRAME-RATE 属性的 HLS 主播放列表，
+// 播放器据此在各档位之间自适应切换。档位按码率从低到高排列。
+func BuildMasterPlaylist(variants []HLSVariant) string {
+	sorted := make([]HLSVariant, len(variants))
+	copy(sorted, variants)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bandwidth < sorted[j].Bandwidth })
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:7\n#EXT-X-INDEPENDENT-SEGMENTS\n\n")
+
+	// 每个档位的音轨来自同一次转码，单独成组，保证和视频分片时间轴一致
+	for i, v := range sorted {
+		if v.AudioURI != "" {
+			fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio%d\",NAME=\"audio\",DEFAULT=YES,AUTOSELECT=YES,URI=\"%s\"\n", i, v.AudioURI)
+		}
+	}
+
+	for i, v := range sorted {
+		attrs := []string{fmt.Sprintf("BANDWIDTH=%d", v.Bandwidth)}
+		if v.AverageBandwidth > 0 {
+			attrs = append(attrs, fmt.Sprintf("AVERAGE-BANDWIDTH=%d", v.AverageBandwidth))
+		}
+		if v.Width > 0 && v.Height > 0 {
+			attrs = append(attrs, fmt.Sprintf("RESOLUTION=%dx%d", v.Width, v.Height))
+		}
+		if v.Codecs != "" {
+			attrs = append(attrs, fmt.Sprintf("CODECS=\"%s\"", v.Codecs))
+		}
+		if v.FrameRate > 0 {
+			attrs = append(attrs, "FRAME-RATE="+strconv.FormatFloat(v.FrameRate, 'f', 3, 64))
+		}
+		if v.AudioURI != "" {
+			attrs = append(attrs, fmt.Sprintf("AUDIO=\"audio%d\"", i))
+		}
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:%s\n%s\n", strings.Join(attrs, ","), v.URI)
+	}
+	return b.String()
+}
+
+// HLSSubtitle 是主播放列表字幕组中的一条字幕轨道 (EXT-X-MEDIA TYPE=SUBTITLES)
+type HLSSubtitle struct {
+	URI      string // 字幕媒体播放列表，相对主播放列表的路径
+	Language string // BCP 47 语言标签，例如 "en"、"zh-CN"
+	Name     string // 播放器中显示的名称
+	Default  bool
+}
+
+// subtitleGroupID 是字幕组的 GROUP-ID
+const subtitleGroupID = "subs"
+
+// uriAttrPattern 匹配 EXT-X-MEDIA 等标签中的 URI 属性
+var uriAttrPattern = regexp.MustCompile(`URI="([^"]*)"`)
+
+// WithSubtitles 在已有的主播放列表中加入字幕组: 所有相对 URI 加上 baseURI 前缀
+// (新的主播放列表与原来的不在同一目录)，每个 EXT-X-STREAM-INF 都引用字幕组
+func WithSubtitles(master, baseURI string, subtitles []HLSSubtitle) string {
+	rebase := func(uri string) string {
+		if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+			return uri
+		}
+		return baseURI + uri
+	}
+
+	var b strings.Builder
+	inserted := false
+	for _, line := range strings.Split(strings.TrimRight(master, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			if !inserted {
+				for _, s := range subtitles {
+					fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"%s\",NAME=\"%s\",LANGUAGE=\"%s\",DEFAULT=%s,AUTOSELECT=YES,URI=\"%s\"\n",
+						subtitleGroupID, s.Name, s.Language, yesNo(s.Default), s.URI)
+				}
+				inserted = true
+			}
+			line += fmt.Sprintf(",SUBTITLES=\"%s\"", subtitleGroupID)
+		case strings.HasPrefix(line, "#"):
+			line = uriAttrPattern.ReplaceAllStringFunc(line, func(attr string) string {
+				return `URI="` + rebase(uriAttrPattern.FindStringSubmatch(attr)[1]) + `"`
+			})
+		case line != "":
+			line = rebase(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// BuildSubtitlePlaylist 生成只有一个 WebVTT 分片的字幕媒体播放列表，duration 为视频时长 (秒)
+func BuildSubtitlePlaylist(vttURI string, duration float64) string {
+	return fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\n%s\n#EXT-X-ENDLIST\n",
+		int(math.Ceil(duration)), duration, vttURI)
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "YES"
+	}
+	return "NO"
+}
+
+// PlaylistBitrate 根据本地媒体播放列表中的 EXTINF 时长和分片文件大小计算峰值和平均码率 (bit/s)
+func PlaylistBitrate(playlistPath string) (peak, average uint64, err error) {
+	f, err := os.Open(playlistPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(playlistPath)
+	var totalBytes int64
+	var totalSeconds, duration float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, _ = strconv.ParseFloat(value, 64)
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		default:
+			info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(line)))
+			if err != nil {
+				return 0, 0, err
+			}
+			if duration > 0 {
+				if rate := uint64(float64(info.Size()*8) / duration); rate > peak {
+					peak = rate
+				}
+			}
+			totalBytes += info.Size()
+			totalSeconds += duration
+			duration = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if totalSeconds > 0 {
+		average = uint64(float64(totalBytes*8) / totalSeconds)
+	}
+	return peak, average, nil
+}
+
+// h264ProfileIDC 把 ffprobe 的 H.264 profile 名称映射为 avc1 编码字符串中的 profile_idc 和约束标志
+var h264ProfileIDC = map[string]string{
+	"Constrained Baseline":  "42E0",
+	"Baseline":              "4200",
+	"Main":                  "4D40",
+	"Extended":              "5800",
+	"High":                  "6400",
+	"High 10":               "6E00",
+	"High 4:2:2":            "7A00",
+	"High 4:4:4 Predictive": "F400",
+}
+
+// CodecString 返回单个流的 RFC 6381 编码字符串，无法识别时返回空字符串
+func CodecString(s *ProbeStream) string {
+	switch s.CodecName {
+	case "h264":
+		idc, ok := h264ProfileIDC[s.Profile]
+		if !ok || s.Level <= 0 {
+			return ""
+		}
+		return fmt.Sprintf("avc1.%s%02X", idc, s.Level)
+	case "hevc":
+		// ffprobe 的 HEVC level 已经是 general_level_idc (级别 x 30)
+		switch s.Profile {
+		case "Main":
+			return fmt.Sprintf("hvc1.1.6.L%d.B0", s.Level)
+		case "Main 10":
+			return fmt.Sprintf("hvc1.2.4.L%d.B0", s.Level)
+		}
+		return ""
+	case "aac":
+		if s.Profile == "HE-AAC" {
+			return "mp4a.40.5"
+		}
+		if s.Profile == "HE-AACv2" {
+			return "mp4a.40.29"
+		}
+		return "mp4a.40.2"
+	case "mp3":
+		return "mp4a.40.34"
+	case "opus":
+		return "opus"
+	}
+	return ""
+}
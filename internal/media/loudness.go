@@ -0,0 +1,83 @@
+// internal/media/loudness.go
+package media
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// LoudnessTarget 是 loudnorm 标准化的目标
+type LoudnessTarget struct {
+	IntegratedLUFS float64
+	TruePeakDBTP   float64
+	LoudnessRange  float64
+}
+
+// LoudnessMeasurement 是 loudnorm 第一遍测量的结果 (EBU R128)
+type LoudnessMeasurement struct {
+	IntegratedLUFS float64 // 综合响度
+	TruePeakDBTP   float64 // 真峰值
+	LoudnessRange  float64 // 响度范围 (LU)
+	Threshold      float64 // 门限
+	TargetOffset   float64 // 第二遍使用的增益偏移
+}
+
+// LoudnormMeasureFilter 返回第一遍测量用的滤镜，测量结果以 JSON 打印到 stderr
+func LoudnormMeasureFilter(t LoudnessTarget) string {
+	return fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g:print_format=json", t.IntegratedLUFS, t.TruePeakDBTP, t.LoudnessRange)
+}
+
+// LoudnormFilter 返回第二遍标准化用的滤镜。传入第一遍的测量值后 loudnorm 可以使用线性增益，
+// 不会像单遍处理那样改变动态范围 (真峰值超限时 loudnorm 会自动退回动态模式)
+func LoudnormFilter(t LoudnessTarget, m *LoudnessMeasurement) string {
+	return fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g:measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:offset=%.2f:linear=true:print_format=none",
+		t.IntegratedLUFS, t.TruePeakDBTP, t.LoudnessRange,
+		m.IntegratedLUFS, m.TruePeakDBTP, m.LoudnessRange, m.Threshold, m.TargetOffset)
+}
+
+// ParseLoudnormStats 从 ffmpeg 的 stderr 中读取 loudnorm 打印的 JSON (输出中最后一个 {...} 块)。
+// 静音的音频综合响度为 -inf，无法标准化，返回错误。
+func ParseLoudnormStats(output []byte) (*LoudnessMeasurement, error) {
+	start := bytes.LastIndexByte(output, '{')
+	end := bytes.LastIndexByte(output, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("loudnorm statistics not found in ffmpeg output")
+	}
+	var raw struct {
+		InputI       string `json:"input_i"`
+		InputTP      string `json:"input_tp"`
+		InputLRA     string `json:"input_lra"`
+		InputThresh  string `json:"input_thresh"`
+		TargetOffset string `json:"target_offset"`
+	}
+	if err := json.Unmarshal(output[start:end+1], &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse loudnorm statistics: %w", err)
+	}
+
+	m := &LoudnessMeasurement{}
+	values := []struct {
+		name string
+		text string
+		dst  *float64
+	}{
+		{"input_i", raw.InputI, &m.IntegratedLUFS},
+		{"input_tp", raw.InputTP, &m.TruePeakDBTP},
+		{"input_lra", raw.InputLRA, &m.LoudnessRange},
+		{"input_thresh", raw.InputThresh, &m.Threshold},
+		{"target_offset", raw.TargetOffset, &m.TargetOffset},
+	}
+	for _, v := range values {
+		f, err := strconv.ParseFloat(v.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid loudnorm %s %q", v.name, v.text)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("loudnorm %s is %s, the audio is silent", v.name, v.text)
+		}
+		*v.dst = f
+	}
+	return m, nil
+}
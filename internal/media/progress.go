@@ -0,0 +1,43 @@
+// internal/media/progress.go
+package media
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress 是 ffmpeg -progress 输出的一个进度块
+type Progress struct {
+	OutTime time.Duration // 已经输出的媒体时长
+	Speed   float64       // 处理速度，相对实时播放的倍数，未知时为 0
+	Done    bool          // progress=end
+}
+
+// ReadProgress 解析 ffmpeg -progress pipe:1 的 key=value 输出，每读完一个进度块 (以 progress= 结尾) 调用一次 fn，
+// 直到 r 结束。ffmpeg 的 out_time_ms 实际也是微秒，这里优先使用 out_time_us。
+func ReadProgress(r io.Reader, fn func(Progress)) error {
+	var current Progress
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// 开始阶段可能输出 N/A 或负数
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				current.OutTime = time.Duration(us) * time.Microsecond
+			}
+		case "speed":
+			current.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "x"), 64)
+		case "progress":
+			current.Done = value == "end"
+			fn(current)
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,262 @@
+// internal/media/subtitles.go
+package media
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SubtitleCue 是一条字幕
+type SubtitleCue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string // 多行用 \n 分隔，不含空行
+}
+
+// 支持上传的字幕格式
+const (
+	SubtitleFormatSRT    = "srt"
+	SubtitleFormatWebVTT = "vtt"
+	SubtitleFormatASS    = "ass"
+	SubtitleFormatSSA    = "ssa"
+)
+
+var (
+	// assOverridePattern 匹配 ASS 的样式覆盖标签，例如 {\an8}、{\i1}
+	assOverridePattern = regexp.MustCompile(`\{[^}]*\}`)
+	// srtFontTagPattern 匹配 SRT 中常见但 WebVTT 不支持的 <font ...> 标签
+	srtFontTagPattern = regexp.MustCompile(`(?i)</?font[^>]*>`)
+	// srtStyleTagPattern 匹配转义后的 <b> <i> <u> 标签，WebVTT 同样支持，转义后恢复
+	srtStyleTagPattern = regexp.MustCompile(`(?i)&lt;(/?[biu])&gt;`)
+	// vttTextEscaper 转义 cue 文本中 WebVTT 的保留字符
+	vttTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	// blankLinePattern 匹配分隔字幕块的空行
+	blankLinePattern = regexp.MustCompile(`\n[ \t]*\n`)
+	// timestampPartPattern 匹配时间戳中以冒号分隔的一段
+	timestampPartPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// ParseSubtitles 按格式解析字幕文件，返回按开始时间排序的字幕。文件必须是 UTF-8 编码 (可以带 BOM)
+func ParseSubtitles(data []byte, format string) ([]SubtitleCue, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("subtitle file must be UTF-8 encoded")
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+
+	var cues []SubtitleCue
+	var err error
+	switch format {
+	case SubtitleFormatSRT:
+		cues, err = parseSRT(text)
+	case SubtitleFormatWebVTT:
+		cues, err = parseWebVTT(text)
+	case SubtitleFormatASS, SubtitleFormatSSA:
+		cues, err = parseASS(text)
+	default:
+		return nil, fmt.Errorf("unsupported subtitle format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("subtitle file contains no cues")
+	}
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].Start < cues[j].Start })
+	return cues, nil
+}
+
+// BuildWebVTT 把字幕输出为 WebVTT 文件
+func BuildWebVTT(cues []SubtitleCue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for _, c := range cues {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", vttTimestamp(c.Start.Seconds()), vttTimestamp(c.End.Seconds()), c.Text)
+	}
+	return b.String()
+}
+
+// parseSRT 解析 SubRip: 空行分隔的块，每块为序号 (可省略)、时间行和若干行文本
+func parseSRT(text string) ([]SubtitleCue, error) {
+	var cues []SubtitleCue
+	for _, block := range splitBlocks(text) {
+		lines := strings.Split(block, "\n")
+		if !strings.Contains(lines[0], "-->") {
+			lines = lines[1:] // 序号
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		start, end, err := parseTimingLine(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		body := srtFontTagPattern.ReplaceAllString(strings.Join(lines[1:], "\n"), "")
+		body = assOverridePattern.ReplaceAllString(body, "") // 部分 SRT 带有 {\an8} 之类的 ASS 标签
+		body = srtStyleTagPattern.ReplaceAllStringFunc(vttTextEscaper.Replace(body), func(tag string) string {
+			return strings.ToLower(strings.NewReplacer("&lt;", "<", "&gt;", ">").Replace(tag))
+		})
+		if cue, ok := newCue(start, end, body); ok {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// parseWebVTT 解析 WebVTT，丢弃 NOTE / STYLE / REGION 块、cue 标识和 cue 设置
+func parseWebVTT(text string) ([]SubtitleCue, error) {
+	blocks := splitBlocks(text)
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+
+	var cues []SubtitleCue
+	for _, block := range blocks[1:] {
+		lines := strings.Split(block, "\n")
+		if strings.HasPrefix(lines[0], "NOTE") || lines[0] == "STYLE" || lines[0] == "REGION" {
+			continue
+		}
+		if !strings.Contains(lines[0], "-->") {
+			lines = lines[1:] // cue 标识
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		start, end, err := parseTimingLine(lines[0])
+		if err != nil {
+			return nil, err
+		}
+		if cue, ok := newCue(start, end, strings.Join(lines[1:], "\n")); ok {
+			cues = append(cues, cue)
+		}
+	}
+	return cues, nil
+}
+
+// parseASS 解析 ASS / SSA 的 [Events] 段，按 Format 行确定 Start / End / Text 字段的位置，样式全部丢弃
+func parseASS(text string) ([]SubtitleCue, error) {
+	var cues []SubtitleCue
+	inEvents := false
+	var fields []string
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Format":
+			fields = strings.Split(value, ",")
+			for j := range fields {
+				fields[j] = strings.TrimSpace(fields[j])
+			}
+		case "Dialogue":
+			if len(fields) == 0 || fields[len(fields)-1] != "Text" {
+				return nil, fmt.Errorf("line %d: dialogue before a valid Format line", i+1)
+			}
+			// Text 是最后一个字段，其中可能含有逗号
+			values := strings.SplitN(value, ",", len(fields))
+			if len(values) != len(fields) {
+				return nil, fmt.Errorf("line %d: expected %d fields", i+1, len(fields))
+			}
+			var start, end time.Duration
+			var err error
+			for j, name := range fields {
+				switch name {
+				case "Start":
+					start, err = parseTimestamp(strings.TrimSpace(values[j]))
+				case "End":
+					end, err = parseTimestamp(strings.TrimSpace(values[j]))
+				}
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+			}
+			body := assOverridePattern.ReplaceAllString(values[len(values)-1], "")
+			body = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(body)
+			body = vttTextEscaper.Replace(body)
+			if cue, ok := newCue(start, end, body); ok {
+				cues = append(cues, cue)
+			}
+		}
+	}
+	return cues, nil
+}
+
+// splitBlocks 按空行切分，去掉每块首尾的空白
+func splitBlocks(text string) []string {
+	var blocks []string
+	for _, block := range blankLinePattern.Split(text, -1) {
+		if block = strings.Trim(block, " \t\n"); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// parseTimingLine 解析 "00:00:01,000 --> 00:00:04,000 ..." 形式的时间行，忽略结束时间之后的设置
+func parseTimingLine(line string) (time.Duration, time.Duration, error) {
+	left, right, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid timing line %q", line)
+	}
+	rightFields := strings.Fields(right)
+	if len(rightFields) == 0 {
+		return 0, 0, fmt.Errorf("invalid timing line %q", line)
+	}
+	start, err := parseTimestamp(strings.TrimSpace(left))
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimestamp(rightFields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// parseTimestamp 解析 [hh:]mm:ss[.,]fff 形式的时间戳，小数部分可以是任意位数 (ASS 为百分之一秒)
+func parseTimestamp(value string) (time.Duration, error) {
+	parts := strings.Split(strings.Replace(value, ",", ".", 1), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", value)
+	}
+	var total float64
+	for i, part := range parts {
+		if !timestampPartPattern.MatchString(part) || (i < len(parts)-1 && strings.Contains(part, ".")) {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", value)
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total * float64(time.Second)).Round(time.Millisecond), nil
+}
+
+// newCue 清理字幕文本 (去掉空行和会被当作时间行的 "-->")，文本为空或时间无效时返回 false
+func newCue(start, end time.Duration, body string) (SubtitleCue, bool) {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(strings.ReplaceAll(line, "-->", "->")); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 || end <= start {
+		return SubtitleCue{}, false
+	}
+	return SubtitleCue{Start: start, End: end, Text: strings.Join(lines, "\n")}, true
+}
@@ -0,0 +1,48 @@
+// internal/media/thumbnails.go
+package media
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SpriteLayout 描述雪碧图的排列: 每 Interval 秒一张 Width x Height 的缩略图，按行排满 Columns x Rows 后换下一张雪碧图
+type SpriteLayout struct {
+	Interval float64
+	Width    int
+	Height   int
+	Columns  int
+	Rows     int
+	// SheetName 返回第 n 张雪碧图 (从 1 开始，与 ffmpeg 的 %03d 输出一致) 相对 VTT 文件的路径
+	SheetName func(n int) string
+}
+
+// BuildThumbnailVTT 生成 WebVTT 缩略图轨道，每个 cue 指向雪碧图中的一个区域 (#xywh=x,y,w,h)。
+// count 为实际生成的缩略图数量，duration 为视频时长 (秒)。
+func BuildThumbnailVTT(layout SpriteLayout, count int, duration float64) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+
+	perSheet := layout.Columns * layout.Rows
+	for i := 0; i < count; i++ {
+		start := float64(i) * layout.Interval
+		if start >= duration {
+			break
+		}
+		end := math.Min(start+layout.Interval, duration)
+
+		pos := i % perSheet
+		x := (pos % layout.Columns) * layout.Width
+		y := (pos / layout.Columns) * layout.Height
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			vttTimestamp(start), vttTimestamp(end), layout.SheetName(i/perSheet+1), x, y, layout.Width, layout.Height)
+	}
+	return b.String()
+}
+
+// vttTimestamp 把秒数格式化为 WebVTT 的 hh:mm:ss.ttt
+func vttTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,67 @@
+// internal/media/waveform.go
+package media
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Waveform 是降采样后的波形数据，格式与 BBC audiowaveform 的 JSON 输出 (version 2) 一致，
+// 可以直接交给 peaks.js / wavesurfer.js 等播放器组件使用
+type Waveform struct {
+	Version         int    `json:"version"`
+	Channels        int    `json:"channels"`
+	SampleRate      int    `json:"sample_rate"`
+	SamplesPerPixel int    `json:"samples_per_pixel"`
+	Bits            int    `json:"bits"`
+	Length          int    `json:"length"` // 点数
+	Data            []int8 `json:"data"`   // 每个点一对 最小值, 最大值
+}
+
+// ComputeWaveform 从 16 位有符号小端单声道 PCM (ffmpeg -f s16le -ac 1) 计算波形，
+// 每 samplesPerPixel 个采样取一对最小值 / 最大值，并缩放到 8 位
+func ComputeWaveform(r io.Reader, sampleRate, samplesPerPixel int) (*Waveform, error) {
+	if sampleRate <= 0 || samplesPerPixel <= 0 {
+		return nil, fmt.Errorf("invalid waveform parameters: sample_rate %d, samples_per_pixel %d", sampleRate, samplesPerPixel)
+	}
+	w := &Waveform{
+		Version:         2,
+		Channels:        1,
+		SampleRate:      sampleRate,
+		SamplesPerPixel: samplesPerPixel,
+		Bits:            8,
+	}
+
+	br := bufio.NewReader(r)
+	var sample [2]byte
+	var lo, hi int16
+	count := 0
+	for {
+		if _, err := io.ReadFull(br, sample[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, err
+		}
+		v := int16(binary.LittleEndian.Uint16(sample[:]))
+		if count == 0 || v < lo {
+			lo = v
+		}
+		if count == 0 || v > hi {
+			hi = v
+		}
+		count++
+		if count == samplesPerPixel {
+			w.Data = append(w.Data, int8(lo>>8), int8(hi>>8))
+			count = 0
+		}
+	}
+	if count > 0 {
+		w.Data = append(w.Data, int8(lo>>8), int8(hi>>8))
+	}
+	w.Length = len(w.Data) / 2
+	return w, nil
+}
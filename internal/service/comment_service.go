@@ -37,4 +37,8 @@ func ListCommentsService(videoID uint64) ([]model.Comment, error) {
 	// 使用 Preload("User") 来预加载关联的用户数据
 	err := dal.DB.Preload("User").Where("video_id = ?", videoID).Order("created_at asc").Find(&comments).Error
 	return comments, err
-}
\ No newline at end of file
+}
+// DeleteCommentService 删除评论，调用前需通过 AuthorizeComment 的权限检查
+func DeleteCommentService(commentID uint64) error {
+	return dal.DB.Delete(&model.Comment{}, commentID).Error
+}
@@ -0,0 +1,178 @@
+// internal/service/content_key_service.go
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContentKeySize 是 HLS AES-128 内容密钥的长度
+const ContentKeySize = 16
+
+var (
+	// ErrContentKeyNotFound 视频没有内容密钥 (未加密或还没有转码)
+	ErrContentKeyNotFound = errors.New("content key not found")
+	// ErrInvalidPlaybackToken 播放令牌无效、已过期或不属于该视频
+	ErrInvalidPlaybackToken = errors.New("invalid or expired playback token")
+)
+
+// PlaybackToken 是获取加密视频密钥用的短期令牌，播放器请求密钥时以 ?token= 附加
+type PlaybackToken struct {
+	Token     string    `json:"token"      example:"eyJhbGciOiJIUzI1NiIs..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// contentKeyAEAD 用主密钥创建 AES-256-GCM，用于加密保存在数据库中的内容密钥
+func contentKeyAEAD() (cipher.AEAD, error) {
+	masterKey, err := config.AppConfig.Encryption.MasterKeyBytes()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// contentKeyAAD 把密文绑定到视频 ID，复制到其他视频的记录上无法解密
+func contentKeyAAD(videoID uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte("video-key:"), videoID)
+}
+
+// sealContentKey 加密内容密钥，返回 nonce || 密文
+func sealContentKey(videoID uint64, key []byte) ([]byte, error) {
+	aead, err := contentKeyAEAD()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key, contentKeyAAD(videoID)), nil
+}
+
+// openContentKey 解密 sealContentKey 的结果
+func openContentKey(videoID uint64, sealed []byte) ([]byte, error) {
+	aead, err := contentKeyAEAD()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("content key of video %d is truncated", videoID)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	key, err := aead.Open(nil, nonce, ciphertext, contentKeyAAD(videoID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt content key of video %d (was encryption.master_key changed?): %w", videoID, err)
+	}
+	return key, nil
+}
+
+// GetContentKeyService 返回视频解密后的内容密钥，视频没有密钥时返回 ErrContentKeyNotFound
+func GetContentKeyService(videoID uint64) ([]byte, error) {
+	var record model.VideoKey
+	if err := dal.DB.First(&record, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContentKeyNotFound
+		}
+		return nil, err
+	}
+	return openContentKey(videoID, record.EncryptedKey)
+}
+
+// GetOrCreateContentKey 返回视频的内容密钥，没有时生成一个。任务重试时沿用同一个密钥，
+// 上次已经加密上传的档位仍然可以解密
+func GetOrCreateContentKey(videoID uint64) ([]byte, error) {
+	key, err := GetContentKeyService(videoID)
+	if !errors.Is(err, ErrContentKeyNotFound) {
+		return key, err
+	}
+
+	key = make([]byte, ContentKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	sealed, err := sealContentKey(videoID, key)
+	if err != nil {
+		return nil, err
+	}
+	// 并发执行的任务可能同时生成密钥，以先写入的为准
+	if err := dal.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.VideoKey{VideoID: videoID, EncryptedKey: sealed}).Error; err != nil {
+		return nil, err
+	}
+	return GetContentKeyService(videoID)
+}
+
+// ContentKeyURL 返回写入 HLS 播放列表 (EXT-X-KEY URI) 的密钥地址
+func ContentKeyURL(videoID uint64) string {
+	return fmt.Sprintf("%s/videos/%d/key", config.AppConfig.Encryption.KeyURLBase, videoID)
+}
+
+// playbackTokenSecret 由 JWT 密钥派生，播放令牌和登录令牌不能互相冒用
+func playbackTokenSecret() []byte {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWT.Secret))
+	mac.Write([]byte("playback-token"))
+	return mac.Sum(nil)
+}
+
+// IssuePlaybackTokenService 为能观看该视频的调用者签发播放令牌，视频未加密时返回 nil
+func IssuePlaybackTokenService(actor Actor, video *model.Video) (*PlaybackToken, error) {
+	if !canViewVideo(actor, video) {
+		return nil, nil
+	}
+	var count int64
+	if err := dal.DB.Model(&model.VideoKey{}).Where("video_id = ?", video.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(config.AppConfig.Encryption.PlaybackTokenTTLSeconds) * time.Second)
+	claims := jwt.MapClaims{
+		"video_id": video.ID,
+		"exp":      expiresAt.Unix(),
+		"iat":      now.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(playbackTokenSecret())
+	if err != nil {
+		return nil, err
+	}
+	return &PlaybackToken{Token: token, ExpiresAt: time.Unix(expiresAt.Unix(), 0)}, nil
+}
+
+// VerifyPlaybackToken 校验播放令牌是否有效且属于该视频，否则返回 ErrInvalidPlaybackToken
+func VerifyPlaybackToken(tokenString string, videoID uint64) error {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return playbackTokenSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPlaybackToken, err)
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return ErrInvalidPlaybackToken
+	}
+	if id, ok := claims["video_id"].(float64); !ok || uint64(id) != videoID {
+		return fmt.Errorf("%w: token was issued for another video", ErrInvalidPlaybackToken)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// internal/service/cover_service.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // 注册 PNG 解码器
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器
+	"gorm.io/gorm"
+)
+
+const (
+	// coverUploadExpiry 自定义封面预签名上传地址的有效期
+	coverUploadExpiry = 15 * time.Minute
+	// maxCoverPixels 自定义封面解码前允许的最大像素数，防止解压炸弹
+	maxCoverPixels = 50_000_000
+	// minCoverSide 自定义封面的最小边长
+	minCoverSide = 64
+)
+
+// ErrCoverCandidateNotFound 候选封面不存在或不属于该视频
+var ErrCoverCandidateNotFound = errors.New("cover candidate not found")
+
+// CoverUpload 是自定义封面的预签名上传地址，客户端 PUT 上传后用 UploadKey 调用设置封面接口
+type CoverUpload struct {
+	UploadURL string
+	UploadKey string
+	ExpiresIn time.Duration
+}
+
+// coverObjectPrefix 返回视频自定义封面的目录。与 processed/<id>/ 不同，它不会在内容相同的视频之间共享
+func coverObjectPrefix(videoID uint64) string {
+	return fmt.Sprintf("covers/%d", videoID)
+}
+
+// randomObjectName 生成随机的对象名
+func randomObjectName(prefix string) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err) // crypto/rand 不会失败
+	}
+	return prefix + hex.EncodeToString(buf)
+}
+
+// checkCoverEditable 转码结束前封面会被 Worker 覆盖，不允许修改
+func checkCoverEditable(video *model.Video) error {
+	if video.Status == "uploading" || video.Status == "transcoding" {
+		return &UploadVerificationError{
+			Status:  http.StatusConflict,
+			Code:    "video_not_ready",
+			Message: fmt.Sprintf("cover cannot be changed while the video is %s", video.Status),
+		}
+	}
+	return nil
+}
+
+// ListCoverCandidatesService 返回视频的候选封面，URL 为带签名的临时地址
+func ListCoverCandidatesService(video *model.Video) ([]model.VideoCoverCandidate, error) {
+	var candidates []model.VideoCoverCandidate
+	if err := dal.DB.Where("video_id = ?", video.ID).Order("id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	for i := range candidates {
+		signed, err := PresignCoverURL(candidates[i].URL)
+		if err != nil {
+			return nil, err
+		}
+		candidates[i].URL = signed
+	}
+	return candidates, nil
+}
+
+// PresignCoverURL 为封面对象生成带签名的临时 URL，对象路径为空时返回空字符串
+func PresignCoverURL(objectName string) (string, error) {
+	if objectName == "" {
+		return "", nil
+	}
+	presignedURL, err := dal.MinioClient.PresignedGetObject(context.Background(),
+		config.AppConfig.MinIO.BucketName, objectName, time.Minute*15, make(url.Values))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned url for cover %s: %w", objectName, err)
+	}
+	return presignedURL.String(), nil
+}
+
+// PresignCoverUploadService 为自定义封面生成预签名 PUT URL，对象路径为 covers/<id>/upload-<随机名>
+func PresignCoverUploadService(video *model.Video) (*CoverUpload, error) {
+	if err := checkCoverEditable(video); err != nil {
+		return nil, err
+	}
+	uploadKey := randomObjectName(coverObjectPrefix(video.ID) + "/upload-")
+	urlObj, err := dal.MinioClient.PresignedPutObject(context.Background(),
+		config.AppConfig.MinIO.BucketName, uploadKey, coverUploadExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &CoverUpload{UploadURL: urlObj.String(), UploadKey: uploadKey, ExpiresIn: coverUploadExpiry}, nil
+}
+
+// SelectCoverCandidateService 把视频封面设置为它的一张候选封面，返回新的封面对象路径
+func SelectCoverCandidateService(video *model.Video, candidateID uint64) (string, error) {
+	if err := checkCoverEditable(video); err != nil {
+		return "", err
+	}
+	var candidate model.VideoCoverCandidate
+	if err := dal.DB.Where("id = ? AND video_id = ?", candidateID, video.ID).First(&candidate).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrCoverCandidateNotFound
+		}
+		return "", err
+	}
+	if err := replaceCover(context.Background(), video, candidate.URL); err != nil {
+		return "", err
+	}
+	return candidate.URL, nil
+}
+
+// SetCustomCoverService 校验客户端上传的图片 (JPEG / PNG / WebP)，缩放并重新编码为 JPEG 后设置为封面，
+// 返回新的封面对象路径。无论成功与否，上传的原始图片都会被删除。
+func SetCustomCoverService(ctx context.Context, video *model.Video, uploadKey string) (string, error) {
+	if err := checkCoverEditable(video); err != nil {
+		return "", err
+	}
+	// 只接受 PresignCoverUploadService 为该视频生成的路径
+	uploadPrefix := coverObjectPrefix(video.ID) + "/upload-"
+	if !strings.HasPrefix(uploadKey, uploadPrefix) || strings.ContainsAny(uploadKey[len(uploadPrefix):], "/.") {
+		return "", &UploadVerificationError{Status: http.StatusBadRequest, Code: "invalid_upload_key", Message: "invalid upload_key"}
+	}
+
+	bucketName := config.AppConfig.MinIO.BucketName
+	cfg := config.AppConfig.Cover
+
+	info, err := dal.MinioClient.StatObject(ctx, bucketName, uploadKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return "", &UploadVerificationError{
+				Status:    http.StatusConflict,
+				Code:      "object_not_found",
+				Message:   "uploaded cover not found, the upload may not have finished yet",
+				Retryable: true,
+			}
+		}
+		return "", fmt.Errorf("failed to stat uploaded cover: %w", err)
+	}
+	defer func() {
+		if err := dal.MinioClient.RemoveObject(context.Background(), bucketName, uploadKey, minio.RemoveObjectOptions{}); err != nil {
+			log.Printf("Failed to remove uploaded cover %s: %v", uploadKey, err)
+		}
+	}()
+
+	maxBytes := cfg.MaxUploadSizeMB << 20
+	if maxBytes > 0 && info.Size > maxBytes {
+		return "", &UploadVerificationError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "file_too_large",
+			Message: fmt.Sprintf("uploaded cover is %d bytes, the limit is %d MB", info.Size, cfg.MaxUploadSizeMB),
+		}
+	}
+
+	obj, err := dal.MinioClient.GetObject(ctx, bucketName, uploadKey, minio.GetObjectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded cover: %w", err)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(io.LimitReader(obj, info.Size))
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded cover: %w", err)
+	}
+
+	// 先只读取图片头，格式和尺寸合法后再完整解码
+	imgConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", &UploadVerificationError{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    "unsupported_image",
+			Message: "cover must be a JPEG, PNG or WebP image",
+		}
+	}
+	if imgConfig.Width < minCoverSide || imgConfig.Height < minCoverSide ||
+		imgConfig.Width*imgConfig.Height > maxCoverPixels {
+		return "", &UploadVerificationError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "invalid_dimensions",
+			Message: fmt.Sprintf("cover is %dx%d, each side must be at least %d pixels and the total at most %d pixels", imgConfig.Width, imgConfig.Height, minCoverSide, maxCoverPixels),
+		}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", &UploadVerificationError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "corrupt_image",
+			Message: fmt.Sprintf("failed to decode %s image: %v", format, err),
+		}
+	}
+
+	// 重新编码同时去掉了 EXIF 等元数据
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, media.ResizeToFit(img, cfg.MaxWidth, cfg.MaxHeight), &jpeg.Options{Quality: cfg.JPEGQuality}); err != nil {
+		return "", fmt.Errorf("failed to encode cover: %w", err)
+	}
+	coverKey := randomObjectName(coverObjectPrefix(video.ID)+"/custom-") + ".jpg"
+	if _, err := dal.MinioClient.PutObject(ctx, bucketName, coverKey, &buf, int64(buf.Len()),
+		minio.PutObjectOptions{ContentType: "image/jpeg"}); err != nil {
+		return "", fmt.Errorf("failed to upload cover: %w", err)
+	}
+
+	if err := replaceCover(ctx, video, coverKey); err != nil {
+		dal.MinioClient.RemoveObject(context.Background(), bucketName, coverKey, minio.RemoveObjectOptions{})
+		return "", err
+	}
+	return coverKey, nil
+}
+
+// replaceCover 更新 videos.cover_url，并删除被替换掉的自定义封面 (候选封面保留，之后还可以再选)
+func replaceCover(ctx context.Context, video *model.Video, coverKey string) error {
+	previous := video.CoverURL
+	if err := dal.DB.Model(video).Update("cover_url", coverKey).Error; err != nil {
+		return err
+	}
+	if previous != coverKey && strings.HasPrefix(previous, coverObjectPrefix(video.ID)+"/") {
+		if err := dal.MinioClient.RemoveObject(ctx, config.AppConfig.MinIO.BucketName, previous, minio.RemoveObjectOptions{}); err != nil {
+			log.Printf("Failed to remove previous cover %s of video %d: %v", previous, video.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+// internal/service/dlq_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+	"github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
+)
+
+// 转码任务消息的 headers
+const (
+	HeaderRetryCount = "x-retry-count" // 已经失败的次数
+	HeaderLastError  = "x-last-error"
+	HeaderFailedAt   = "x-failed-at" // 转入死信队列的时间 (RFC 3339)
+)
+
+// ErrDeadLetterDisabled 没有配置死信队列
+var ErrDeadLetterDisabled = errors.New("transcode dead-letter queue is not configured")
+
+// ErrRawObjectMissing 视频的原始文件已不存在，重新投递也不会成功，需要重新上传
+var ErrRawObjectMissing = errors.New("raw object no longer exists, the video must be uploaded again")
+
+// ErrReplayUnmigrated 旧版本创建的视频还没有 object_key，需要先运行 cmd/migrate-keys
+var ErrReplayUnmigrated = errors.New("video has no object key, run cmd/migrate-keys first")
+
+// DeadLetterTask 是死信队列中的一个转码任务
+type DeadLetterTask struct {
+	VideoID   uint64 `json:"video_id"   example:"123"`
+	Attempts  int    `json:"attempts"   example:"4"`
+	LastError string `json:"last_error" example:"exit status 1"`
+	FailedAt  string `json:"failed_at"  example:"2025-01-01T00:00:00Z"`
+}
+
+// TaskAttempts 返回消息 headers 中记录的失败次数，没有时为 0
+func TaskAttempts(headers amqp091.Table) int {
+	switch v := headers[HeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+func newDeadLetterTask(d *amqp091.Delivery) DeadLetterTask {
+	var payload TranscodeTaskPayload
+	json.Unmarshal(d.Body, &payload)
+	task := DeadLetterTask{VideoID: payload.VideoID, Attempts: TaskAttempts(d.Headers)}
+	task.LastError, _ = d.Headers[HeaderLastError].(string)
+	task.FailedAt, _ = d.Headers[HeaderFailedAt].(string)
+	return task
+}
+
+// deadLetterChannel 为死信队列的操作单独打开一个通道: 取出但未确认的消息会在通道关闭时自动退回队列
+func deadLetterChannel() (*amqp091.Channel, string, error) {
+	queue := config.AppConfig.RabbitMQ.TranscodeDeadLetterQueue
+	if queue == "" {
+		return nil, "", ErrDeadLetterDisabled
+	}
+	ch, err := dal.MQConn.Channel()
+	if err != nil {
+		return nil, "", err
+	}
+	return ch, queue, nil
+}
+
+// ListDeadLettersService 查看死信队列中最多 limit 个任务，不会移除它们。total 为队列中的消息总数。
+func ListDeadLettersService(limit int) (tasks []DeadLetterTask, total int, err error) {
+	ch, queue, err := deadLetterChannel()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer ch.Close() // 退回所有取出的消息
+
+	q, err := ch.QueueDeclarePassive(queue, true, false, false, false, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tasks = []DeadLetterTask{}
+	for len(tasks) < limit {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			break
+		}
+		tasks = append(tasks, newDeadLetterTask(&d))
+	}
+	return tasks, q.Messages, nil
+}
+
+// ReplayRejection 是不能重新投递的死信任务
+type ReplayRejection struct {
+	VideoID uint64 `json:"video_id" example:"123"`
+	Error   string `json:"error"    example:"raw object no longer exists, the video must be uploaded again"`
+}
+
+// ReplayResult 是重新投递的结果
+type ReplayResult struct {
+	Replayed []uint64 `json:"replayed" example:"123"`
+	// Rejected 为不能重新投递的任务: 原始文件已不存在的任务移出死信队列，视频标记为 failed；
+	// 没有 object_key 或暂时无法检查原始文件的任务留在死信队列中
+	Rejected []ReplayRejection `json:"rejected"`
+}
+
+// rejectReplay 记录一个暂时不能重新投递的任务，它的消息留在死信队列中
+func rejectReplay(result *ReplayResult, videoID uint64, err error) {
+	log.Printf("Cannot replay dead-lettered task of video %d: %v", videoID, err)
+	result.Rejected = append(result.Rejected, ReplayRejection{VideoID: videoID, Error: err.Error()})
+}
+
+// ReplayDeadLettersService 把死信队列中的任务重新投递到转码队列并清零重试次数，视频恢复为 transcoding。
+// videoIDs 为空时重新投递 (最多 limit 个) 全部任务，否则只投递指定视频的任务。
+// 原始文件已不存在或无法检查的任务不会重新投递，记录在 Rejected 中，不影响其他任务。
+func ReplayDeadLettersService(videoIDs []uint64, limit int) (*ReplayResult, error) {
+	ch, queue, err := deadLetterChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close() // 没有被选中的消息退回死信队列
+
+	wanted := make(map[uint64]bool, len(videoIDs))
+	for _, id := range videoIDs {
+		wanted[id] = true
+	}
+
+	ctx := context.Background()
+	bucketName := config.AppConfig.MinIO.BucketName
+	result := &ReplayResult{Replayed: []uint64{}, Rejected: []ReplayRejection{}}
+	// 不匹配的消息保持未确认，不会被再次取出
+	for len(result.Replayed)+len(result.Rejected) < limit {
+		d, ok, err := ch.Get(queue, false)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			break
+		}
+		task := newDeadLetterTask(&d)
+		if len(wanted) > 0 && !wanted[task.VideoID] {
+			continue
+		}
+
+		// 视频已被删除、重新上传或已由 reaper 重新入队时，该任务已经过时，直接丢弃。
+		// failed 是死信状态上线前转入死信队列的视频，原始文件可能已被 reaper 删除
+		var video model.Video
+		err = dal.DB.Where("id = ? AND status IN ?", task.VideoID, []string{"dead_lettered", "failed"}).First(&video).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+		if err == nil {
+			// 无法检查原始文件时只拒绝这一个视频，不影响同一批次的其他任务；
+			// 消息不确认，留在死信队列中，问题解决后可以再次重新投递
+			if video.RawObjectKey() == "" {
+				rejectReplay(result, video.ID, ErrReplayUnmigrated)
+				continue
+			}
+			_, statErr := dal.MinioClient.StatObject(ctx, bucketName, video.RawObjectKey(), minio.StatObjectOptions{})
+			switch {
+			case minio.ToErrorResponse(statErr).Code == "NoSuchKey":
+				if err := dal.DB.Model(&video).Where("status = ?", video.Status).Update("status", "failed").Error; err != nil {
+					return result, err
+				}
+				log.Printf("Dropping dead-lettered task of video %d: raw object %s is gone", video.ID, video.RawObjectKey())
+				result.Rejected = append(result.Rejected, ReplayRejection{VideoID: video.ID, Error: ErrRawObjectMissing.Error()})
+			case statErr != nil:
+				rejectReplay(result, video.ID, fmt.Errorf("failed to check raw object: %w", statErr))
+				continue
+			default:
+				update := dal.DB.Model(&model.Video{}).Where("id = ? AND status = ?", video.ID, video.Status).Update("status", "transcoding")
+				if update.Error != nil {
+					return result, update.Error
+				}
+				if update.RowsAffected > 0 {
+					if err := dal.PublishTranscodeTaskTo(ctx, config.AppConfig.RabbitMQ.TranscodeQueue, d.Body, nil); err != nil {
+						return result, fmt.Errorf("failed to replay video %d: %w", task.VideoID, err)
+					}
+					result.Replayed = append(result.Replayed, task.VideoID)
+				}
+			}
+		}
+		if err := d.Ack(false); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
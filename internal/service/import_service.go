@@ -0,0 +1,161 @@
+// internal/service/import_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+// ErrImportNotFound 视频不是通过远程 URL 导入的
+var ErrImportNotFound = errors.New("import not found")
+
+// ImportTaskPayload 是发送到导入队列的任务内容
+type ImportTaskPayload struct {
+	VideoID uint64 `json:"video_id"`
+}
+
+// ImportURLError 表示导入地址不合法或不在允许的源站列表中
+type ImportURLError struct {
+	Message string
+}
+
+func (e *ImportURLError) Error() string {
+	return e.Message
+}
+
+// ImportHostAllowed 判断 URL 的 host (可能带端口) 是否命中 import.allowed_hosts。
+// 条目不带端口时匹配任意端口；"*.example.com" 匹配 example.com 的所有子域名，但不匹配 example.com 本身。
+func ImportHostAllowed(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	for _, entry := range config.AppConfig.Import.AllowedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(entryHost, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportContentTypeAllowed 判断源站返回的 Content-Type 是否允许导入，规则与普通上传相同
+func ImportContentTypeAllowed(contentType string) bool {
+	return contentTypeAllowed(contentType)
+}
+
+// ValidateImportURL 检查导入地址的协议和源站
+func ValidateImportURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, &ImportURLError{Message: "invalid source url"}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, &ImportURLError{Message: fmt.Sprintf("unsupported url scheme %q", u.Scheme)}
+	}
+	if u.User != nil {
+		return nil, &ImportURLError{Message: "credentials in source url are not allowed"}
+	}
+	if !ImportHostAllowed(u) {
+		return nil, &ImportURLError{Message: fmt.Sprintf("host %q is not in the import allowlist", u.Host)}
+	}
+	return u, nil
+}
+
+// importFileName 从 URL 路径中取出文件名，作为视频的原始文件名
+func importFileName(u *url.URL) string {
+	name := path.Base(u.Path)
+	if name == "." || name == "/" || name == "" {
+		return "import"
+	}
+	return name
+}
+
+// ImportVideoService 创建视频记录和导入任务，并把任务发送到导入队列，由 Worker 下载到 MinIO
+func ImportVideoService(actor Actor, sourceURL, title, description string) (*model.Video, *model.VideoImport, error) {
+	u, err := ValidateImportURL(sourceURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.AppConfig.RabbitMQ.ImportQueue == "" {
+		return nil, nil, errors.New("remote import is not enabled")
+	}
+	// 文件大小要等 Worker 连上源站才知道，这里只检查当日数量和已用空间
+	if err := checkInitiateQuota(actor, 0); err != nil {
+		return nil, nil, err
+	}
+
+	video := model.Video{
+		UserID:           actor.UserID,
+		Title:            title,
+		Description:      description,
+		OriginalFileName: importFileName(u),
+		Status:           "uploading",
+	}
+	imp := model.VideoImport{SourceURL: u.String(), Status: "pending"}
+	err = dal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createUploadVideo(tx, &video); err != nil {
+			return err
+		}
+		imp.VideoID = video.ID
+		return tx.Create(&imp).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := json.Marshal(ImportTaskPayload{VideoID: video.ID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := dal.PublishImportTask(context.Background(), body); err != nil {
+		FailImport(video.ID, "failed to enqueue import task")
+		return nil, nil, fmt.Errorf("failed to enqueue import task: %w", err)
+	}
+	return &video, &imp, nil
+}
+
+// GetImportService 返回视频的导入任务
+func GetImportService(videoID uint64) (*model.VideoImport, error) {
+	var imp model.VideoImport
+	if err := dal.DB.Where("video_id = ?", videoID).First(&imp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImportNotFound
+		}
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// FailImport 把导入任务和对应的视频标记为失败
+func FailImport(videoID uint64, reason string) {
+	if len(reason) > 1024 {
+		reason = reason[:1024]
+	}
+	dal.DB.Model(&model.VideoImport{}).
+		Where("video_id = ?", videoID).
+		Updates(map[string]interface{}{"status": "failed", "error": reason})
+	markUploadFailed(videoID)
+}
@@ -0,0 +1,235 @@
+// internal/service/media_asset_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVideoBusy 视频正在转码，暂时不能删除
+var ErrVideoBusy = errors.New("video is being transcoded")
+
+// FindMediaAsset 按原始文件的 SHA-256 和水印指纹 (没有水印时为空) 查找可复用的转码产物，没有时返回 nil。
+// 加密的产物使用其所属视频的内容密钥，不参与复用
+func FindMediaAsset(contentHash, watermarkKey string) (*model.MediaAsset, error) {
+	var asset model.MediaAsset
+	err := dal.DB.Where("content_hash = ? AND watermark_key = ? AND NOT encrypted AND ref_count > 0", contentHash, watermarkKey).
+		Order("id").First(&asset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// AttachMediaAsset 让视频复用已有的转码产物: 引用计数加一，复制 VideoSource 记录 (指向共享对象)，
+// 并直接把视频标记为 online。产物已被释放或找不到可复制的播放源时返回 false，调用方应正常转码。
+func AttachMediaAsset(video *model.Video, assetID uint64, contentHash string) (bool, error) {
+	attached := false
+	err := dal.DB.Transaction(func(tx *gorm.DB) error {
+		// 锁住产物记录，和 ReleaseMediaAsset 互斥
+		var asset model.MediaAsset
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&asset, assetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if asset.RefCount == 0 {
+			return nil
+		}
+
+		// 以任意一个仍在引用该产物的已上线视频为模板
+		var template model.Video
+		err := tx.Where("asset_id = ? AND id <> ? AND status = ?", asset.ID, video.ID, "online").First(&template).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var sources []model.VideoSource
+		if err := tx.Where("video_id = ?", template.ID).Find(&sources).Error; err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			return nil
+		}
+
+		// 之前中断的转码可能已经写入了部分档位的播放源
+		if err := tx.Where("video_id = ?", video.ID).Delete(&model.VideoSource{}).Error; err != nil {
+			return err
+		}
+		for i := range sources {
+			sources[i].ID = 0
+			sources[i].VideoID = video.ID
+			sources[i].CreatedAt = time.Time{}
+		}
+		if err := tx.Create(&sources).Error; err != nil {
+			return err
+		}
+		// 候选封面位于共享目录，同样复制记录
+		var candidates []model.VideoCoverCandidate
+		if err := tx.Where("video_id = ?", template.ID).Order("id").Find(&candidates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("video_id = ?", video.ID).Delete(&model.VideoCoverCandidate{}).Error; err != nil {
+			return err
+		}
+		for i := range candidates {
+			candidates[i].ID = 0
+			candidates[i].VideoID = video.ID
+			candidates[i].CreatedAt = time.Time{}
+		}
+		if len(candidates) > 0 {
+			if err := tx.Create(&candidates).Error; err != nil {
+				return err
+			}
+		}
+		// 播放源的音频相同，响度记录也一并复制
+		if err := tx.Where("video_id = ?", video.ID).Delete(&model.VideoLoudness{}).Error; err != nil {
+			return err
+		}
+		var loudness model.VideoLoudness
+		if err := tx.Where("video_id = ?", template.ID).Limit(1).Find(&loudness).Error; err != nil {
+			return err
+		}
+		if loudness.VideoID != 0 {
+			loudness.VideoID = video.ID
+			loudness.CreatedAt = time.Time{}
+			if err := tx.Create(&loudness).Error; err != nil {
+				return err
+			}
+		}
+		// 复用的是未加密的产物，删除之前加密转码时生成的内容密钥
+		if err := tx.Where("video_id = ?", video.ID).Delete(&model.VideoKey{}).Error; err != nil {
+			return err
+		}
+		// 模板视频的自定义封面属于模板视频本身 (covers/<id>/)，不能共享，改用默认封面
+		coverURL := template.CoverURL
+		if !strings.HasPrefix(coverURL, asset.StoragePrefix+"/") {
+			coverURL = ""
+			if len(candidates) > 0 {
+				coverURL = candidates[0].URL
+			}
+		}
+
+		if err := tx.Model(&asset).Update("ref_count", gorm.Expr("ref_count + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(video).Updates(map[string]interface{}{
+			"status":            "online",
+			"duration":          template.Duration,
+			"cover_url":         coverURL,
+			"thumbnail_vtt_url": template.ThumbnailVTTURL,
+			"media_type":        template.MediaType,
+			"waveform_url":      template.WaveformURL,
+			"content_hash":      contentHash,
+			"asset_id":          asset.ID,
+		}).Error; err != nil {
+			return err
+		}
+		attached = true
+		return nil
+	})
+	return attached, err
+}
+
+// ReleaseMediaAsset 在事务中把产物的引用计数减一。
+// 计数归零时删除产物记录并返回其对象目录，由调用方在事务提交后删除对象。
+func ReleaseMediaAsset(tx *gorm.DB, assetID uint64) (string, error) {
+	var asset model.MediaAsset
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&asset, assetID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if asset.RefCount > 1 {
+		return "", tx.Model(&asset).Update("ref_count", gorm.Expr("ref_count - 1")).Error
+	}
+	if err := tx.Delete(&asset).Error; err != nil {
+		return "", err
+	}
+	return asset.StoragePrefix, nil
+}
+
+// RemoveObjectsWithPrefix 删除 prefix 目录下的所有对象
+func RemoveObjectsWithPrefix(ctx context.Context, prefix string) error {
+	bucketName := config.AppConfig.MinIO.BucketName
+	objects := dal.MinioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: prefix + "/", Recursive: true})
+	for rerr := range dal.MinioClient.RemoveObjects(ctx, bucketName, objects, minio.RemoveObjectsOptions{}) {
+		if rerr.Err != nil {
+			return fmt.Errorf("failed to remove %s: %w", rerr.ObjectName, rerr.Err)
+		}
+	}
+	return nil
+}
+
+// DeleteVideoService 删除视频及其评论、播放源等记录。
+// 共享的转码产物只有在最后一个引用它的视频被删除时才会从 MinIO 中删除。
+func DeleteVideoService(videoID uint64) error {
+	var processedPrefix string
+	err := dal.DB.Transaction(func(tx *gorm.DB) error {
+		var video model.Video
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&video, videoID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVideoNotFound
+			}
+			return err
+		}
+		// 转码中的视频由 Worker 持有，等转码结束 (或被 reaper 处理) 后再删除
+		if video.Status == "transcoding" {
+			return ErrVideoBusy
+		}
+
+		if video.AssetID != nil {
+			prefix, err := ReleaseMediaAsset(tx, *video.AssetID)
+			if err != nil {
+				return err
+			}
+			processedPrefix = prefix
+		} else {
+			// 去重功能上线前转码的视频独占自己的目录
+			processedPrefix = fmt.Sprintf("processed/%d", video.ID)
+		}
+
+		// comments / video_sources / upload_sessions 等通过外键级联删除
+		return tx.Delete(&video).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if processedPrefix != "" {
+		if err := RemoveObjectsWithPrefix(ctx, processedPrefix); err != nil {
+			log.Printf("Failed to remove processed objects of video %d: %v", videoID, err)
+		}
+	}
+	// 自定义封面 (包括未确认的上传) 和字幕只属于该视频
+	if err := RemoveObjectsWithPrefix(ctx, coverObjectPrefix(videoID)); err != nil {
+		log.Printf("Failed to remove cover objects of video %d: %v", videoID, err)
+	}
+	if err := RemoveObjectsWithPrefix(ctx, subtitleObjectPrefix(videoID)); err != nil {
+		log.Printf("Failed to remove subtitle objects of video %d: %v", videoID, err)
+	}
+	// 残留的原始文件和分片上传也会由 reaper 清理，这里尽早删除
+	if err := RemoveObjectsWithPrefix(ctx, fmt.Sprintf("raw/%d", videoID)); err != nil {
+		log.Printf("Failed to remove raw objects of video %d: %v", videoID, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// internal/service/metadata_service.go
+package service
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewVideoMetadata 把 ffprobe 的输出整理为 video_metadata 记录
+func NewVideoMetadata(videoID uint64, probe *media.ProbeResult) *model.VideoMetadata {
+	meta := &model.VideoMetadata{
+		VideoID:   videoID,
+		Container: probe.Format.FormatName,
+		Duration:  probe.DurationSeconds(),
+		Streams:   make([]model.StreamMetadata, 0, len(probe.Streams)),
+	}
+	meta.BitRate, _ = strconv.ParseInt(probe.Format.BitRate, 10, 64)
+	if t := probe.CreationTime(); !t.IsZero() {
+		meta.CreationTime = &t
+	}
+
+	for i := range probe.Streams {
+		s := &probe.Streams[i]
+		stream := model.StreamMetadata{
+			Index:       s.Index,
+			Type:        s.CodecType,
+			Codec:       s.CodecName,
+			Profile:     s.Profile,
+			PixelFormat: s.PixFmt,
+			Channels:    s.Channels,
+			Language:    s.Tags["language"],
+		}
+		stream.BitRate, _ = strconv.ParseInt(s.BitRate, 10, 64)
+		stream.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		if s.CodecType == "video" {
+			stream.Width, stream.Height = s.Width, s.Height
+			stream.FrameRate = s.FrameRate()
+			stream.Rotation = s.Rotation()
+		}
+		meta.Streams = append(meta.Streams, stream)
+	}
+
+	// 内嵌的专辑封面不算视频流，纯音频文件的宽高和帧率为 0
+	if v := probe.PrimaryVideoStream(); v != nil {
+		meta.VideoCodec = v.CodecName
+		meta.Width, meta.Height = v.DisplaySize()
+		meta.FrameRate = v.FrameRate()
+		meta.Rotation = v.Rotation()
+	}
+	if a := probe.FirstStream("audio"); a != nil {
+		meta.AudioCodec = a.CodecName
+	}
+	return meta
+}
+
+// SaveVideoMetadata 写入视频元数据，重新转码时覆盖旧记录
+func SaveVideoMetadata(meta *model.VideoMetadata) error {
+	return dal.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(meta).Error
+}
+
+// GetVideoMetadataService 返回视频的原始文件元数据，仅所有者和管理员可见。
+// 无权查看或尚未提取 (转码前、旧视频) 时返回 nil。
+func GetVideoMetadataService(actor Actor, video *model.Video) (*model.VideoMetadata, error) {
+	if !canManageVideo(actor, video) {
+		return nil, nil
+	}
+	var meta model.VideoMetadata
+	err := dal.DB.First(&meta, video.ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// GetVideoLoudnessService 返回视频的响度测量结果，未做响度标准化时返回 nil
+func GetVideoLoudnessService(videoID uint64) (*model.VideoLoudness, error) {
+	var loudness model.VideoLoudness
+	err := dal.DB.First(&loudness, videoID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loudness, nil
+}
@@ -0,0 +1,250 @@
+// internal/service/multipart_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+const (
+	minPartSize  = 5 << 20 // S3 要求除最后一片外，每个分片至少 5MB
+	maxPartCount = 10000   // S3 单个对象最多 10000 个分片
+)
+
+// ErrUploadSessionNotFound 视频没有进行中的分片上传会话 (从未开启、已完成或已取消)
+var ErrUploadSessionNotFound = errors.New("no active multipart upload")
+
+// multipartPartSize 根据配置和文件大小计算分片大小，保证分片数不超过 maxPartCount
+func multipartPartSize(fileSize uint64) uint64 {
+	partSize := uint64(config.AppConfig.Upload.PartSizeMB) << 20
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+	for fileSize > 0 && (fileSize+partSize-1)/partSize > maxPartCount {
+		partSize *= 2
+	}
+	return partSize
+}
+
+// InitiateMultipartUploadService 创建视频记录并在 MinIO 上开启一个分片上传会话
+func InitiateMultipartUploadService(
+	actor Actor,
+	fileName string,
+	title string,
+	description string,
+	fileSize uint64,
+) (*model.Video, *model.UploadSession, error) {
+	return initiateUploadSession(actor, fileName, title, description, fileSize, "multipart")
+}
+
+// initiateUploadSession 是预签名分片上传和 tus 上传共用的会话创建逻辑
+func initiateUploadSession(
+	actor Actor,
+	fileName string,
+	title string,
+	description string,
+	fileSize uint64,
+	protocol string,
+) (*model.Video, *model.UploadSession, error) {
+	if err := checkInitiateQuota(actor, fileSize); err != nil {
+		return nil, nil, err
+	}
+
+	video := model.Video{
+		UserID:           actor.UserID,
+		Title:            title,
+		Description:      description,
+		OriginalFileName: fileName,
+		Status:           "uploading",
+	}
+	if err := createUploadVideo(dal.DB, &video); err != nil {
+		return nil, nil, err
+	}
+
+	objectKey := video.RawObjectKey()
+	uploadID, err := dal.MinioCore.NewMultipartUpload(context.Background(),
+		config.AppConfig.MinIO.BucketName,
+		objectKey,
+		minio.PutObjectOptions{ContentType: mime.TypeByExtension(path.Ext(objectKey))},
+	)
+	if err != nil {
+		dal.DB.Model(&video).Update("status", "failed")
+		return nil, nil, fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	session := model.UploadSession{
+		VideoID:   video.ID,
+		UploadID:  uploadID,
+		ObjectKey: objectKey,
+		PartSize:  multipartPartSize(fileSize),
+		FileSize:  fileSize,
+		Protocol:  protocol,
+		Status:    "active",
+	}
+	if err := dal.DB.Create(&session).Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &video, &session, nil
+}
+
+// getActiveUploadSession 查询视频当前处于 active 状态的分片上传会话
+func getActiveUploadSession(videoID uint64) (*model.UploadSession, error) {
+	var session model.UploadSession
+	err := dal.DB.Where("video_id = ? AND status = ?", videoID, "active").First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w for video %d", ErrUploadSessionNotFound, videoID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// PresignUploadPartService 为指定分片生成预签名 PUT URL
+func PresignUploadPartService(videoID uint64, partNumber int) (string, error) {
+	if partNumber < 1 || partNumber > maxPartCount {
+		return "", fmt.Errorf("part number must be between 1 and %d", maxPartCount)
+	}
+
+	session, err := getActiveUploadSession(videoID)
+	if err != nil {
+		return "", err
+	}
+
+	reqParams := make(url.Values)
+	reqParams.Set("uploadId", session.UploadID)
+	reqParams.Set("partNumber", strconv.Itoa(partNumber))
+	urlObj, err := dal.MinioClient.Presign(context.Background(), "PUT",
+		config.AppConfig.MinIO.BucketName,
+		session.ObjectKey,
+		uploadPresignExpiry(),
+		reqParams,
+	)
+	if err != nil {
+		return "", err
+	}
+	return urlObj.String(), nil
+}
+
+// listAllParts 分页列出一个分片上传会话中所有已上传的分片
+func listAllParts(ctx context.Context, session *model.UploadSession) ([]minio.ObjectPart, error) {
+	var parts []minio.ObjectPart
+	marker := 0
+	for {
+		result, err := dal.MinioCore.ListObjectParts(ctx,
+			config.AppConfig.MinIO.BucketName,
+			session.ObjectKey,
+			session.UploadID,
+			marker,
+			1000,
+		)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, result.ObjectParts...)
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// ListUploadedPartsService 返回已上传的分片列表，客户端据此跳过已完成的分片实现断点续传
+func ListUploadedPartsService(videoID uint64) (*model.UploadSession, []minio.ObjectPart, error) {
+	session, err := getActiveUploadSession(videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := listAllParts(context.Background(), session)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, parts, nil
+}
+
+// AbortMultipartUploadService 放弃分片上传，清理 MinIO 上已上传的分片
+func AbortMultipartUploadService(videoID uint64) error {
+	session, err := getActiveUploadSession(videoID)
+	if err != nil {
+		return err
+	}
+
+	if err := dal.MinioCore.AbortMultipartUpload(context.Background(),
+		config.AppConfig.MinIO.BucketName,
+		session.ObjectKey,
+		session.UploadID,
+	); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	if err := dal.DB.Model(session).Update("status", "aborted").Error; err != nil {
+		return err
+	}
+	return dal.DB.Model(&model.Video{}).Where("id = ?", videoID).Update("status", "failed").Error
+}
+
+// completeMultipartSession 把所有已上传的分片按序号合并为最终对象
+func completeMultipartSession(ctx context.Context, session *model.UploadSession) error {
+	parts, err := listAllParts(ctx, session)
+	if err != nil {
+		if recovered, rerr := recoverCompletedSession(ctx, session, err); recovered || rerr != nil {
+			return rerr
+		}
+		return fmt.Errorf("failed to list uploaded parts: %w", err)
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("no parts have been uploaded for video %d", session.VideoID)
+	}
+
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, p := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+	sort.Slice(completeParts, func(i, j int) bool {
+		return completeParts[i].PartNumber < completeParts[j].PartNumber
+	})
+
+	if _, err := dal.MinioCore.CompleteMultipartUpload(ctx,
+		config.AppConfig.MinIO.BucketName,
+		session.ObjectKey,
+		session.UploadID,
+		completeParts,
+		minio.PutObjectOptions{},
+	); err != nil {
+		if recovered, rerr := recoverCompletedSession(ctx, session, err); recovered || rerr != nil {
+			return rerr
+		}
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return dal.DB.Model(session).Update("status", "completed").Error
+}
+
+// recoverCompletedSession 处理上一次合并已经成功、但没能把会话标记为 completed 的情况:
+// MinIO 返回 NoSuchUpload 且对象已经存在时补上 completed 状态，返回 true。
+// 其他错误不做处理，返回 false 和 nil，由调用方返回原来的错误
+func recoverCompletedSession(ctx context.Context, session *model.UploadSession, cause error) (bool, error) {
+	if minio.ToErrorResponse(cause).Code != "NoSuchUpload" {
+		return false, nil
+	}
+	if _, err := dal.MinioClient.StatObject(ctx, config.AppConfig.MinIO.BucketName, session.ObjectKey, minio.StatObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat uploaded object: %w", err)
+	}
+	return true, dal.DB.Model(session).Update("status", "completed").Error
+}
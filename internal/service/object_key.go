@@ -0,0 +1,85 @@
+// internal/service/object_key.go
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxDisplayNameBytes 与 videos.original_file_name 的长度一致
+	maxDisplayNameBytes = 255
+	// maxKeyExtensionLen 对象路径中保留的扩展名最大长度
+	maxKeyExtensionLen = 8
+	// defaultDisplayName 清理后为空时使用的显示名
+	defaultDisplayName = "video"
+)
+
+// SanitizeDisplayName 清理客户端提供的文件名，仅用于展示 (videos.original_file_name)。
+// 去掉目录部分、无效 UTF-8、控制字符和不可见的格式字符 (例如 U+202E)，并截断到 255 字节。
+func SanitizeDisplayName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToValidUTF8(name, "") {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	name = strings.TrimSpace(b.String())
+
+	for len(name) > maxDisplayNameBytes {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	if name == "" || name == "." || name == ".." {
+		return defaultDisplayName
+	}
+	return name
+}
+
+// safeKeyExtension 从文件名中取出只含小写字母和数字的扩展名 (带点)，不合法时返回空字符串
+func safeKeyExtension(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	if ext == "" || len(ext) > maxKeyExtensionLen {
+		return ""
+	}
+	for _, r := range ext {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return ""
+		}
+	}
+	return "." + ext
+}
+
+// NewRawObjectKey 生成原始文件的对象路径 raw/<video_id>/<随机名>.<扩展名>。
+// 路径完全由服务端生成，只保留清理过的扩展名，供 Content-Type 推断和 ffmpeg 识别格式。
+func NewRawObjectKey(video *model.Video) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err) // crypto/rand 不会失败
+	}
+	return path.Join("raw", video.IDString(), hex.EncodeToString(buf)+safeKeyExtension(video.OriginalFileName))
+}
+
+// createUploadVideo 创建视频记录，清理显示名并分配服务端生成的原始文件对象路径
+func createUploadVideo(db *gorm.DB, video *model.Video) error {
+	video.OriginalFileName = SanitizeDisplayName(video.OriginalFileName)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(video).Error; err != nil {
+			return err
+		}
+		video.ObjectKey = NewRawObjectKey(video)
+		return tx.Model(video).Update("object_key", video.ObjectKey).Error
+	})
+}
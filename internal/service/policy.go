@@ -0,0 +1,115 @@
+// internal/service/policy.go
+package service
+
+import (
+	"errors"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+// 权限检查统一返回以下两个错误，handler 据此返回 404 / 403
+var (
+	// ErrVideoNotFound 视频不存在，或者调用者无权知道它的存在
+	ErrVideoNotFound = errors.New("video not found")
+	// ErrCommentNotFound 评论不存在
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrForbidden 调用者可以看到资源，但无权执行该操作
+	ErrForbidden = errors.New("permission denied")
+)
+
+// Actor 表示发起请求的用户，来自 JWTAuthMiddleware 写入的 user_id / role
+type Actor struct {
+	UserID uint64
+	Role   string
+}
+
+func (a Actor) IsAdmin() bool   { return a.Role == "admin" }
+func (a Actor) IsAuditor() bool { return a.Role == "auditor" }
+
+// VideoAction 是针对单个视频的操作类型
+type VideoAction int
+
+const (
+	// VideoActionView 查看视频。公开 (online) 的视频任何人可见，其余状态仅所有者、管理员和审核员可见
+	VideoActionView VideoAction = iota
+	// VideoActionComment 发表评论，可见即可评论
+	VideoActionComment
+	// VideoActionManage 修改视频 (完成上传、分片/tus 上传、删除等)，仅所有者和管理员
+	VideoActionManage
+)
+
+// canViewVideo 判断调用者能否看到该视频
+func canViewVideo(actor Actor, video *model.Video) bool {
+	return video.Status == "online" ||
+		video.UserID == actor.UserID ||
+		actor.IsAdmin() ||
+		actor.IsAuditor()
+}
+
+// canManageVideo 判断调用者能否管理该视频: 所有者或管理员
+func canManageVideo(actor Actor, video *model.Video) bool {
+	return video.UserID == actor.UserID || actor.IsAdmin()
+}
+
+// AuthorizeVideo 加载视频并检查调用者是否有权执行 action。
+// 看不到的视频一律返回 ErrVideoNotFound，避免泄露其他用户未公开视频的存在；
+// 看得到但无权操作时返回 ErrForbidden。
+func AuthorizeVideo(actor Actor, videoID uint64, action VideoAction) (*model.Video, error) {
+	var video model.Video
+	if err := dal.DB.First(&video, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+
+	if err := checkVideoAction(actor, &video, action); err != nil {
+		return nil, err
+	}
+	return &video, nil
+}
+
+// checkVideoAction 判断调用者能否对已加载的视频执行 action: 看不到时返回 ErrVideoNotFound，
+// 看得到但无权操作时返回 ErrForbidden
+func checkVideoAction(actor Actor, video *model.Video, action VideoAction) error {
+	if !canViewVideo(actor, video) {
+		return ErrVideoNotFound
+	}
+
+	switch action {
+	case VideoActionView, VideoActionComment:
+		return nil
+	case VideoActionManage:
+		if canManageVideo(actor, video) {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
+
+// AuthorizeComment 检查调用者能否删除评论: 评论作者、管理员或审核员
+func AuthorizeComment(actor Actor, videoID, commentID uint64) (*model.Comment, error) {
+	if _, err := AuthorizeVideo(actor, videoID, VideoActionView); err != nil {
+		return nil, err
+	}
+
+	var comment model.Comment
+	if err := dal.DB.Where("id = ? AND video_id = ?", commentID, videoID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	if canDeleteComment(actor, &comment) {
+		return &comment, nil
+	}
+	return nil, ErrForbidden
+}
+
+// canDeleteComment 判断调用者能否删除评论: 评论作者、管理员或审核员
+func canDeleteComment(actor Actor, comment *model.Comment) bool {
+	return comment.UserID == actor.UserID || actor.IsAdmin() || actor.IsAuditor()
+}
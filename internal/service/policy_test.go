@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cjh/video-platform-go/internal/dal/model"
+)
+
+const videoOwnerID = 1
+
+var (
+	actorOwner     = Actor{UserID: videoOwnerID, Role: "user"}
+	actorOther     = Actor{UserID: 2, Role: "user"}
+	actorAdmin     = Actor{UserID: 3, Role: "admin"}
+	actorAuditor   = Actor{UserID: 4, Role: "auditor"}
+	actorAnonymous = Actor{} // OptionalJWTAuthMiddleware 没有写入 claims 时
+)
+
+// 除 online 外的状态都不公开
+var hiddenStatuses = []string{"uploading", "transcoding", "failed", "dead_lettered", "private"}
+
+func TestCheckVideoAction(t *testing.T) {
+	// want: nil 对应 2xx，ErrVideoNotFound 对应 404，ErrForbidden 对应 403 (见 handler.writePolicyError)
+	cases := []struct {
+		name   string
+		actor  Actor
+		public bool
+		action VideoAction
+		want   error
+	}{
+		{"owner views public", actorOwner, true, VideoActionView, nil},
+		{"owner comments on public", actorOwner, true, VideoActionComment, nil},
+		{"owner manages public", actorOwner, true, VideoActionManage, nil},
+		{"owner views hidden", actorOwner, false, VideoActionView, nil},
+		{"owner comments on hidden", actorOwner, false, VideoActionComment, nil},
+		{"owner manages hidden", actorOwner, false, VideoActionManage, nil},
+
+		{"admin views public", actorAdmin, true, VideoActionView, nil},
+		{"admin comments on public", actorAdmin, true, VideoActionComment, nil},
+		{"admin manages public", actorAdmin, true, VideoActionManage, nil},
+		{"admin views hidden", actorAdmin, false, VideoActionView, nil},
+		{"admin comments on hidden", actorAdmin, false, VideoActionComment, nil},
+		{"admin manages hidden", actorAdmin, false, VideoActionManage, nil},
+
+		{"auditor views public", actorAuditor, true, VideoActionView, nil},
+		{"auditor comments on public", actorAuditor, true, VideoActionComment, nil},
+		{"auditor manages public", actorAuditor, true, VideoActionManage, ErrForbidden},
+		{"auditor views hidden", actorAuditor, false, VideoActionView, nil},
+		{"auditor comments on hidden", actorAuditor, false, VideoActionComment, nil},
+		{"auditor manages hidden", actorAuditor, false, VideoActionManage, ErrForbidden},
+
+		{"other user views public", actorOther, true, VideoActionView, nil},
+		{"other user comments on public", actorOther, true, VideoActionComment, nil},
+		{"other user manages public", actorOther, true, VideoActionManage, ErrForbidden},
+		{"other user views hidden", actorOther, false, VideoActionView, ErrVideoNotFound},
+		{"other user comments on hidden", actorOther, false, VideoActionComment, ErrVideoNotFound},
+		{"other user manages hidden", actorOther, false, VideoActionManage, ErrVideoNotFound},
+
+		{"anonymous views public", actorAnonymous, true, VideoActionView, nil},
+		{"anonymous comments on public", actorAnonymous, true, VideoActionComment, nil},
+		{"anonymous manages public", actorAnonymous, true, VideoActionManage, ErrForbidden},
+		{"anonymous views hidden", actorAnonymous, false, VideoActionView, ErrVideoNotFound},
+		{"anonymous comments on hidden", actorAnonymous, false, VideoActionComment, ErrVideoNotFound},
+		{"anonymous manages hidden", actorAnonymous, false, VideoActionManage, ErrVideoNotFound},
+	}
+
+	for _, tc := range cases {
+		statuses := []string{"online"}
+		if !tc.public {
+			statuses = hiddenStatuses
+		}
+		for _, status := range statuses {
+			video := &model.Video{ID: 10, UserID: videoOwnerID, Status: status}
+			if got := checkVideoAction(tc.actor, video, tc.action); !errors.Is(got, tc.want) {
+				t.Errorf("%s (%s): got %v, want %v", tc.name, status, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestCanViewAndManageVideo(t *testing.T) {
+	cases := []struct {
+		name       string
+		actor      Actor
+		status     string
+		wantView   bool
+		wantManage bool
+	}{
+		{"owner, online", actorOwner, "online", true, true},
+		{"owner, private", actorOwner, "private", true, true},
+		{"admin, online", actorAdmin, "online", true, true},
+		{"admin, private", actorAdmin, "private", true, true},
+		{"auditor, online", actorAuditor, "online", true, false},
+		{"auditor, private", actorAuditor, "private", true, false},
+		{"other user, online", actorOther, "online", true, false},
+		{"other user, private", actorOther, "private", false, false},
+		{"anonymous, online", actorAnonymous, "online", true, false},
+		{"anonymous, private", actorAnonymous, "private", false, false},
+	}
+	for _, tc := range cases {
+		video := &model.Video{ID: 10, UserID: videoOwnerID, Status: tc.status}
+		if got := canViewVideo(tc.actor, video); got != tc.wantView {
+			t.Errorf("%s: canViewVideo = %v, want %v", tc.name, got, tc.wantView)
+		}
+		if got := canManageVideo(tc.actor, video); got != tc.wantManage {
+			t.Errorf("%s: canManageVideo = %v, want %v", tc.name, got, tc.wantManage)
+		}
+	}
+}
+
+func TestCanDeleteComment(t *testing.T) {
+	// 评论作者是 actorOther，视频所有者不能删除别人的评论
+	comment := &model.Comment{ID: 20, VideoID: 10, UserID: actorOther.UserID}
+	cases := []struct {
+		name  string
+		actor Actor
+		want  bool
+	}{
+		{"comment author", actorOther, true},
+		{"video owner", actorOwner, false},
+		{"admin", actorAdmin, true},
+		{"auditor", actorAuditor, true},
+		{"anonymous", actorAnonymous, false},
+	}
+	for _, tc := range cases {
+		if got := canDeleteComment(tc.actor, comment); got != tc.want {
+			t.Errorf("%s: canDeleteComment = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
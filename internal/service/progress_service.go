@@ -0,0 +1,96 @@
+// internal/service/progress_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// progressTTL 是转码进度在 Redis 中的保留时间，Worker 崩溃后旧进度会自动过期
+const progressTTL = time.Hour
+
+// ProfileProgress 是单个转码档位的进度
+type ProfileProgress struct {
+	Name    string  `json:"name"    example:"720p"`
+	Percent float64 `json:"percent" example:"42.5"`
+}
+
+// TranscodeProgress 是一个视频的转码进度，由 Worker 写入 Redis 并通过 Pub/Sub 推送
+type TranscodeProgress struct {
+	VideoID  uint64            `json:"video_id" example:"1"`
+	Status   string            `json:"status"   example:"transcoding"` // 视频状态，非 transcoding 时进度不再变化
+	Profile  string            `json:"profile,omitempty" example:"720p"`
+	Profiles []ProfileProgress `json:"profiles"`
+	Percent  float64           `json:"percent"  example:"47.5"` // 总体进度 0-100
+	Speed    float64           `json:"speed"    example:"2.3"`  // 当前档位的处理速度 (相对实时播放的倍数)
+	// ETASeconds 为预计剩余时间，按当前速度估算，未知时为 -1
+	ETASeconds int       `json:"eta_seconds" example:"95"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func progressKey(videoID uint64) string {
+	return fmt.Sprintf("video:%d:progress", videoID)
+}
+
+// ProgressChannel 返回推送该视频转码进度的 Redis Pub/Sub 频道
+func ProgressChannel(videoID uint64) string {
+	return fmt.Sprintf("video:%d:progress:events", videoID)
+}
+
+// PublishTranscodeProgress 保存最新进度并推送给订阅者
+func PublishTranscodeProgress(ctx context.Context, p *TranscodeProgress) error {
+	p.UpdatedAt = time.Now()
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	pipe := dal.Redis.Pipeline()
+	pipe.Set(ctx, progressKey(p.VideoID), data, progressTTL)
+	pipe.Publish(ctx, ProgressChannel(p.VideoID), data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetTranscodeProgressService 返回视频当前的转码进度。
+// 以数据库中的视频状态为准: 已上线为 100%，上传中或失败时没有进度；转码中读取 Worker 最近一次上报的进度。
+func GetTranscodeProgressService(ctx context.Context, videoID uint64) (*TranscodeProgress, error) {
+	var video model.Video
+	if err := dal.DB.Select("id", "status").First(&video, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVideoNotFound
+		}
+		return nil, err
+	}
+
+	progress := &TranscodeProgress{VideoID: video.ID, Status: video.Status, ETASeconds: -1, Profiles: []ProfileProgress{}}
+	switch video.Status {
+	case "online":
+		progress.Percent, progress.ETASeconds = 100, 0
+		return progress, nil
+	case "transcoding":
+	default:
+		return progress, nil
+	}
+
+	data, err := dal.Redis.Get(ctx, progressKey(videoID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// 还在队列中排队，Worker 尚未开始
+		return progress, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, err
+	}
+	progress.Status = video.Status
+	return progress, nil
+}
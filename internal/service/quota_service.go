@@ -0,0 +1,182 @@
+// internal/service/quota_service.go
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+// QuotaExceededError 表示操作会超出用户配额，Status 是建议返回的 HTTP 状态码
+type QuotaExceededError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return e.Message
+}
+
+// QuotaLimits 是某个用户生效的配额，0 表示不限制
+type QuotaLimits struct {
+	MaxStorageBytes    uint64 `json:"max_storage_bytes"`
+	MaxVideosPerDay    int    `json:"max_videos_per_day"`
+	MaxDurationSeconds int    `json:"max_duration_seconds"`
+}
+
+// QuotaUsage 是用户当前的使用量
+type QuotaUsage struct {
+	StorageBytes uint64 `json:"storage_bytes"` // videos.raw_size + video_sources.file_size
+	VideosToday  int    `json:"videos_today"`
+}
+
+// ResolveQuotaLimits 合并角色默认配额和 user_quotas 中的个人覆盖
+func ResolveQuotaLimits(userID uint64, role string) (QuotaLimits, error) {
+	roleLimit := config.AppConfig.Quota.Roles[role]
+	limits := QuotaLimits{
+		MaxStorageBytes:    uint64(roleLimit.MaxStorageMB) << 20,
+		MaxVideosPerDay:    roleLimit.MaxVideosPerDay,
+		MaxDurationSeconds: roleLimit.MaxDurationSeconds,
+	}
+
+	var override model.UserQuota
+	err := dal.DB.First(&override, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return limits, nil
+	}
+	if err != nil {
+		return limits, err
+	}
+	if override.MaxStorageBytes != nil {
+		limits.MaxStorageBytes = *override.MaxStorageBytes
+	}
+	if override.MaxVideosPerDay != nil {
+		limits.MaxVideosPerDay = *override.MaxVideosPerDay
+	}
+	if override.MaxDurationSeconds != nil {
+		limits.MaxDurationSeconds = *override.MaxDurationSeconds
+	}
+	return limits, nil
+}
+
+// ComputeQuotaUsage 计算用户当前的存储和当日上传数量
+func ComputeQuotaUsage(userID uint64) (QuotaUsage, error) {
+	var usage QuotaUsage
+
+	// 1. 转码产物: video_sources.file_size 之和
+	var processed uint64
+	if err := dal.DB.Model(&model.VideoSource{}).
+		Joins("JOIN videos ON videos.id = video_sources.video_id").
+		Where("videos.user_id = ?", userID).
+		Select("COALESCE(SUM(video_sources.file_size), 0)").
+		Scan(&processed).Error; err != nil {
+		return usage, err
+	}
+	usage.StorageBytes = processed
+
+	// 2. 原始文件: 完成上传时记录的 videos.raw_size 之和
+	var raw uint64
+	if err := dal.DB.Model(&model.Video{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(raw_size), 0)").
+		Scan(&raw).Error; err != nil {
+		return usage, err
+	}
+	usage.StorageBytes += raw
+
+	// 3. 今天 (本地时间 0 点起) 创建的视频数
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var today int64
+	if err := dal.DB.Model(&model.Video{}).
+		Where("user_id = ? AND created_at >= ?", userID, startOfDay).
+		Count(&today).Error; err != nil {
+		return usage, err
+	}
+	usage.VideosToday = int(today)
+
+	return usage, nil
+}
+
+// GetQuotaService 返回当前用户的配额和使用量
+func GetQuotaService(actor Actor) (QuotaLimits, QuotaUsage, error) {
+	limits, err := ResolveQuotaLimits(actor.UserID, actor.Role)
+	if err != nil {
+		return limits, QuotaUsage{}, err
+	}
+	usage, err := ComputeQuotaUsage(actor.UserID)
+	return limits, usage, err
+}
+
+// checkInitiateQuota 在创建上传时检查当日数量和 (客户端声明的) 文件大小
+func checkInitiateQuota(actor Actor, declaredSize uint64) error {
+	limits, err := ResolveQuotaLimits(actor.UserID, actor.Role)
+	if err != nil {
+		return err
+	}
+	if limits.MaxVideosPerDay == 0 && limits.MaxStorageBytes == 0 {
+		return nil
+	}
+	usage, err := ComputeQuotaUsage(actor.UserID)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxVideosPerDay > 0 && usage.VideosToday >= limits.MaxVideosPerDay {
+		return &QuotaExceededError{
+			Status:  http.StatusTooManyRequests,
+			Code:    "daily_upload_quota_exceeded",
+			Message: fmt.Sprintf("daily upload limit of %d videos reached", limits.MaxVideosPerDay),
+		}
+	}
+	if limits.MaxStorageBytes > 0 && usage.StorageBytes+declaredSize > limits.MaxStorageBytes {
+		return &QuotaExceededError{
+			Status:  http.StatusForbidden,
+			Code:    "storage_quota_exceeded",
+			Message: fmt.Sprintf("storage quota exceeded: %d of %d bytes used", usage.StorageBytes, limits.MaxStorageBytes),
+		}
+	}
+	return nil
+}
+
+// checkCompleteQuota 在完成上传时按视频所有者的配额检查实际存储和时长。
+// 此时 raw_size 还没有写入，rawSize 是本次上传的原始文件大小，需要加到 usage 上
+func checkCompleteQuota(video *model.Video, durationSeconds float64, rawSize uint64) error {
+	var owner model.User
+	if err := dal.DB.First(&owner, video.UserID).Error; err != nil {
+		return err
+	}
+	limits, err := ResolveQuotaLimits(owner.ID, owner.Role)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxDurationSeconds > 0 && durationSeconds > float64(limits.MaxDurationSeconds) {
+		return &QuotaExceededError{
+			Status:  http.StatusForbidden,
+			Code:    "duration_quota_exceeded",
+			Message: fmt.Sprintf("video is %.0f seconds long, the limit is %d seconds", durationSeconds, limits.MaxDurationSeconds),
+		}
+	}
+	if limits.MaxStorageBytes > 0 {
+		usage, err := ComputeQuotaUsage(owner.ID)
+		if err != nil {
+			return err
+		}
+		if usage.StorageBytes+rawSize > limits.MaxStorageBytes {
+			return &QuotaExceededError{
+				Status:  http.StatusForbidden,
+				Code:    "storage_quota_exceeded",
+				Message: fmt.Sprintf("storage quota exceeded: %d of %d bytes used", usage.StorageBytes, limits.MaxStorageBytes),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+// internal/service/reaper_service.go
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+	"github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
+)
+
+// ReaperStats 统计一次清理执行的各类动作数量
+type ReaperStats struct {
+	ExpiredUploads          int `json:"expired_uploads"`
+	AbortedMultipartUploads int `json:"aborted_multipart_uploads"`
+	RemovedRawObjects       int `json:"removed_raw_objects"`
+	RemovedTusTails         int `json:"removed_tus_tails"`
+	RequeuedTranscodes      int `json:"requeued_transcodes"`
+	FailedTranscodes        int `json:"failed_transcodes"`
+	Errors                  int `json:"errors"`
+}
+
+// Add 把另一次执行的统计累加进来
+func (s *ReaperStats) Add(o ReaperStats) {
+	s.ExpiredUploads += o.ExpiredUploads
+	s.AbortedMultipartUploads += o.AbortedMultipartUploads
+	s.RemovedRawObjects += o.RemovedRawObjects
+	s.RemovedTusTails += o.RemovedTusTails
+	s.RequeuedTranscodes += o.RequeuedTranscodes
+	s.FailedTranscodes += o.FailedTranscodes
+	s.Errors += o.Errors
+}
+
+func (s ReaperStats) String() string {
+	return fmt.Sprintf("expired_uploads=%d aborted_multipart=%d removed_raw=%d removed_tus_tails=%d requeued_transcodes=%d failed_transcodes=%d errors=%d",
+		s.ExpiredUploads, s.AbortedMultipartUploads, s.RemovedRawObjects, s.RemovedTusTails,
+		s.RequeuedTranscodes, s.FailedTranscodes, s.Errors)
+}
+
+// reaperError 记录一次清理中的非致命错误，继续处理下一个对象
+func (s *ReaperStats) reaperError(format string, args ...any) {
+	s.Errors++
+	log.Printf("Reaper: "+format, args...)
+}
+
+// RunReaperPass 执行一次清理。多个 Worker 同时执行是安全的: 所有状态变更都是带条件的更新。
+//  1. 超过预签名有效期仍没有进展的上传标记为 failed，并放弃对应的分片上传
+//  2. 放弃 MinIO 中没有对应活跃会话的残留分片上传
+//  3. 删除 raw/ 下不属于任何待处理视频的原始文件，以及 tmp/tus/ 下的残留尾块
+//  4. transcoding 且心跳超过期限的视频 (Worker 已退出) 按配置重新入队或标记为 failed
+func RunReaperPass(ctx context.Context) ReaperStats {
+	var stats ReaperStats
+	now := time.Now()
+	uploadCutoff := now.Add(-uploadPresignExpiry())
+
+	expireAbandonedUploads(ctx, uploadCutoff, &stats)
+	abortStaleMultipartUploads(ctx, uploadCutoff, &stats)
+	removeOrphanedRawObjects(ctx, uploadCutoff, &stats)
+	removeOrphanedTusTails(ctx, uploadCutoff, &stats)
+
+	deadline := time.Duration(config.AppConfig.Reaper.TranscodeDeadlineMinutes) * time.Minute
+	if deadline <= 0 {
+		deadline = 2 * time.Hour
+	}
+	reapStuckTranscodes(ctx, now.Add(-deadline), &stats)
+	return stats
+}
+
+// expireAbandonedUploads 处理 uploading 状态且视频、上传会话、导入任务在 cutoff 之后都没有更新的视频
+func expireAbandonedUploads(ctx context.Context, cutoff time.Time, stats *ReaperStats) {
+	var videos []model.Video
+	err := dal.DB.
+		Where("status = ? AND updated_at < ?", "uploading", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM upload_sessions s WHERE s.video_id = videos.id AND s.status = 'active' AND s.updated_at >= ?)", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM video_imports i WHERE i.video_id = videos.id AND i.updated_at >= ?)", cutoff).
+		Find(&videos).Error
+	if err != nil {
+		stats.reaperError("failed to query abandoned uploads: %v", err)
+		return
+	}
+
+	for i := range videos {
+		video := &videos[i]
+		result := dal.DB.Model(&model.Video{}).
+			Where("id = ? AND status = ?", video.ID, "uploading").
+			Update("status", "failed")
+		if result.Error != nil {
+			stats.reaperError("failed to expire upload of video %d: %v", video.ID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue // 其他 Worker 或完成上传请求已经处理
+		}
+		stats.ExpiredUploads++
+		log.Printf("Reaper: expired abandoned upload of video %d (created %s)", video.ID, video.CreatedAt.Format(time.RFC3339))
+
+		var session model.UploadSession
+		err := dal.DB.Where("video_id = ? AND status = ?", video.ID, "active").First(&session).Error
+		if err == nil {
+			abortSessionUpload(ctx, &session, stats)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			stats.reaperError("failed to load upload session of video %d: %v", video.ID, err)
+		}
+
+		dal.DB.Model(&model.VideoImport{}).
+			Where("video_id = ? AND status IN ?", video.ID, []string{"pending", "downloading"}).
+			Updates(map[string]interface{}{"status": "failed", "error": "import expired"})
+	}
+}
+
+// abortSessionUpload 放弃会话对应的 MinIO 分片上传，并把会话标记为 aborted
+func abortSessionUpload(ctx context.Context, session *model.UploadSession, stats *ReaperStats) {
+	bucketName := config.AppConfig.MinIO.BucketName
+	err := dal.MinioCore.AbortMultipartUpload(ctx, bucketName, session.ObjectKey, session.UploadID)
+	if err != nil && minio.ToErrorResponse(err).Code != "NoSuchUpload" {
+		stats.reaperError("failed to abort multipart upload %s of video %d: %v", session.UploadID, session.VideoID, err)
+		return
+	}
+	if err == nil {
+		stats.AbortedMultipartUploads++
+		log.Printf("Reaper: aborted multipart upload %s of video %d", session.UploadID, session.VideoID)
+	}
+	if err := dal.DB.Model(session).Update("status", "aborted").Error; err != nil {
+		stats.reaperError("failed to mark upload session %d aborted: %v", session.ID, err)
+	}
+	if session.Protocol == "tus" {
+		removeObject(ctx, tusTailObjectKey(session.VideoID), &stats.RemovedTusTails, stats)
+	}
+}
+
+// abortStaleMultipartUploads 放弃 raw/ 下发起时间早于 cutoff、且没有对应活跃会话的分片上传
+func abortStaleMultipartUploads(ctx context.Context, cutoff time.Time, stats *ReaperStats) {
+	var activeUploadIDs []string
+	if err := dal.DB.Model(&model.UploadSession{}).Where("status = ?", "active").Pluck("upload_id", &activeUploadIDs).Error; err != nil {
+		stats.reaperError("failed to list active upload sessions: %v", err)
+		return
+	}
+	active := make(map[string]bool, len(activeUploadIDs))
+	for _, id := range activeUploadIDs {
+		active[id] = true
+	}
+
+	bucketName := config.AppConfig.MinIO.BucketName
+	for upload := range dal.MinioClient.ListIncompleteUploads(ctx, bucketName, "raw/", true) {
+		if upload.Err != nil {
+			stats.reaperError("failed to list incomplete uploads: %v", upload.Err)
+			return
+		}
+		if active[upload.UploadID] || upload.Initiated.After(cutoff) {
+			continue
+		}
+		if err := dal.MinioCore.AbortMultipartUpload(ctx, bucketName, upload.Key, upload.UploadID); err != nil {
+			stats.reaperError("failed to abort multipart upload %s (%s): %v", upload.UploadID, upload.Key, err)
+			continue
+		}
+		stats.AbortedMultipartUploads++
+		log.Printf("Reaper: aborted orphaned multipart upload %s (%s, initiated %s)", upload.UploadID, upload.Key, upload.Initiated.Format(time.RFC3339))
+	}
+}
+
+// removeOrphanedRawObjects 删除 raw/ 下修改时间早于 cutoff 的孤立原始文件:
+// 视频已不存在、视频已失败，或者对象不是视频当前的原始文件。
+// 任务在死信队列中的视频 (dead_lettered) 保留原始文件，以便重新投递
+func removeOrphanedRawObjects(ctx context.Context, cutoff time.Time, stats *ReaperStats) {
+	bucketName := config.AppConfig.MinIO.BucketName
+	videos := make(map[uint64]*model.Video)
+
+	for obj := range dal.MinioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: "raw/", Recursive: true}) {
+		if obj.Err != nil {
+			stats.reaperError("failed to list raw objects: %v", obj.Err)
+			return
+		}
+		if obj.LastModified.After(cutoff) {
+			continue
+		}
+
+		reason := ""
+		videoID, ok := videoIDFromRawKey(obj.Key)
+		if !ok {
+			reason = "unrecognized key"
+		} else {
+			video, cached := videos[videoID]
+			if !cached {
+				var v model.Video
+				err := dal.DB.First(&v, videoID).Error
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					stats.reaperError("failed to load video %d: %v", videoID, err)
+					continue
+				}
+				if err == nil {
+					video = &v
+				}
+				videos[videoID] = video
+			}
+			switch {
+			case video == nil:
+				reason = "video deleted"
+			case video.RawObjectKey() == "":
+				// 还没有运行 cmd/migrate-keys，对象仍在旧路径下，无法判断是否孤立
+				continue
+			case video.Status == "failed":
+				reason = "video failed"
+			case video.RawObjectKey() != obj.Key:
+				reason = "not the video's raw file"
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		if removeObject(ctx, obj.Key, &stats.RemovedRawObjects, stats) {
+			log.Printf("Reaper: removed orphaned raw object %s (%s, %d bytes)", obj.Key, reason, obj.Size)
+			// 失败视频的原始文件删除后不再占用配额
+			if video := videos[videoID]; video != nil && video.RawObjectKey() == obj.Key {
+				if err := dal.DB.Model(video).Update("raw_size", 0).Error; err != nil {
+					stats.reaperError("failed to reset raw size of video %d: %v", videoID, err)
+				}
+			}
+		}
+	}
+}
+
+// removeOrphanedTusTails 删除 tmp/tus/ 下没有对应活跃 tus 会话的尾块
+func removeOrphanedTusTails(ctx context.Context, cutoff time.Time, stats *ReaperStats) {
+	bucketName := config.AppConfig.MinIO.BucketName
+	for obj := range dal.MinioClient.ListObjects(ctx, bucketName, minio.ListObjectsOptions{Prefix: "tmp/tus/", Recursive: true}) {
+		if obj.Err != nil {
+			stats.reaperError("failed to list tus tails: %v", obj.Err)
+			return
+		}
+		if obj.LastModified.After(cutoff) {
+			continue
+		}
+		idStr := strings.TrimSuffix(strings.TrimPrefix(obj.Key, "tmp/tus/"), ".part")
+		if videoID, err := strconv.ParseUint(idStr, 10, 64); err == nil {
+			var active int64
+			if err := dal.DB.Model(&model.UploadSession{}).
+				Where("video_id = ? AND status = ?", videoID, "active").
+				Count(&active).Error; err != nil {
+				stats.reaperError("failed to check tus session of video %d: %v", videoID, err)
+				continue
+			}
+			if active > 0 {
+				continue
+			}
+		}
+		if removeObject(ctx, obj.Key, &stats.RemovedTusTails, stats) {
+			log.Printf("Reaper: removed orphaned tus tail %s", obj.Key)
+		}
+	}
+}
+
+// removeObject 删除对象，成功时累加 counter
+func removeObject(ctx context.Context, key string, counter *int, stats *ReaperStats) bool {
+	err := dal.MinioClient.RemoveObject(ctx, config.AppConfig.MinIO.BucketName, key, minio.RemoveObjectOptions{})
+	if err != nil {
+		stats.reaperError("failed to remove object %s: %v", key, err)
+		return false
+	}
+	*counter++
+	return true
+}
+
+// TranscodeHeartbeatInterval 是 Worker 转码期间刷新 videos.updated_at 的间隔，
+// reaper.transcode_deadline_minutes 需要明显大于它
+const TranscodeHeartbeatInterval = time.Minute
+
+// reapStuckTranscodes 处理 transcoding 状态且心跳 (updated_at) 早于 cutoff 的视频。
+// 重新入队时沿用并递增失败次数 (videos.transcode_attempts)，每次都让 Worker 崩溃的视频
+// 达到 transcode_max_attempts 后转入死信队列，而不是无限循环
+func reapStuckTranscodes(ctx context.Context, cutoff time.Time, stats *ReaperStats) {
+	var videos []model.Video
+	if err := dal.DB.Where("status = ? AND updated_at < ?", "transcoding", cutoff).
+		Select("id", "transcode_attempts").Find(&videos).Error; err != nil {
+		stats.reaperError("failed to query stuck transcodes: %v", err)
+		return
+	}
+
+	requeue := config.AppConfig.Reaper.StuckTranscodeAction != "fail"
+	mq := config.AppConfig.RabbitMQ
+	for _, video := range videos {
+		videoID := video.ID
+		claim := dal.DB.Model(&model.Video{}).Where("id = ? AND status = ? AND updated_at < ?", videoID, "transcoding", cutoff)
+		if !requeue {
+			result := claim.Update("status", "failed")
+			if result.Error != nil {
+				stats.reaperError("failed to mark stuck transcode of video %d failed: %v", videoID, result.Error)
+			} else if result.RowsAffected > 0 {
+				stats.FailedTranscodes++
+				log.Printf("Reaper: marked stuck transcode of video %d as failed", videoID)
+			}
+			continue
+		}
+
+		// 卡住的这次执行计为一次失败
+		attempts := video.TranscodeAttempts + 1
+		body, _ := json.Marshal(TranscodeTaskPayload{VideoID: videoID})
+		headers := amqp091.Table{
+			HeaderRetryCount: int32(attempts),
+			HeaderLastError:  "transcode stalled: no worker heartbeat",
+		}
+
+		if attempts >= mq.TranscodeMaxAttempts {
+			status := "failed"
+			if mq.TranscodeDeadLetterQueue != "" {
+				status = "dead_lettered"
+			}
+			result := claim.Updates(map[string]interface{}{"status": status, "transcode_attempts": attempts})
+			if result.Error != nil {
+				stats.reaperError("failed to dead-letter stuck transcode of video %d: %v", videoID, result.Error)
+				continue
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if status == "dead_lettered" {
+				headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+				if err := dal.PublishTranscodeTaskTo(ctx, mq.TranscodeDeadLetterQueue, body, headers); err != nil {
+					// 死信消息发不出去时改为 failed，原始文件由 reaper 按失败视频清理
+					dal.DB.Model(&model.Video{}).Where("id = ? AND status = ?", videoID, "dead_lettered").Update("status", "failed")
+					stats.reaperError("failed to dead-letter transcode of video %d: %v", videoID, err)
+					continue
+				}
+			}
+			stats.FailedTranscodes++
+			log.Printf("Reaper: stuck transcode of video %d reached %d attempts, marked %s", videoID, attempts, status)
+			continue
+		}
+
+		// 刷新 updated_at 认领该视频，避免下一轮在期限内重复入队
+		result := claim.Updates(map[string]interface{}{"updated_at": time.Now(), "transcode_attempts": attempts})
+		if result.Error != nil {
+			stats.reaperError("failed to claim stuck transcode of video %d: %v", videoID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		if err := dal.PublishTranscodeTaskTo(ctx, mq.TranscodeQueue, body, headers); err != nil {
+			stats.reaperError("failed to requeue transcode of video %d: %v", videoID, err)
+			continue
+		}
+		stats.RequeuedTranscodes++
+		log.Printf("Reaper: requeued stuck transcode of video %d (attempt %d/%d)", videoID, attempts+1, mq.TranscodeMaxAttempts)
+	}
+}
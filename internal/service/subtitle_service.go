@@ -0,0 +1,355 @@
+// internal/service/subtitle_service.go
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// MaxSubtitleBytes 单个字幕文件的大小上限
+	MaxSubtitleBytes = 2 << 20
+	// maxSubtitleLabelBytes 与 video_subtitles.label 的长度一致
+	maxSubtitleLabelBytes = 100
+)
+
+// ErrSubtitleNotFound 视频没有该语言的字幕
+var ErrSubtitleNotFound = errors.New("subtitle not found")
+
+// languagePattern 匹配 BCP 47 语言标签的常见形式: 语言[-文字][-地区]，例如 en、zh-Hans、pt-BR
+var languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8}){0,2}$`)
+
+// SubtitleUpload 是上传的一个字幕文件
+type SubtitleUpload struct {
+	Language string
+	Label    string
+	Format   string // srt / vtt / ass / ssa
+	Default  bool
+	Data     []byte
+}
+
+// subtitleObjectPrefix 返回视频字幕的目录。字幕属于视频本身，不随转码产物共享
+func subtitleObjectPrefix(videoID uint64) string {
+	return fmt.Sprintf("subtitles/%d", videoID)
+}
+
+// SubtitleMasterPlaylistKey 返回带字幕组的主播放列表。视频有字幕时用它代替转码产物中的 master.m3u8
+func SubtitleMasterPlaylistKey(videoID uint64) string {
+	return subtitleObjectPrefix(videoID) + "/master.m3u8"
+}
+
+// SubtitleFormatFromFileName 根据扩展名判断字幕格式，不支持时返回空字符串
+func SubtitleFormatFromFileName(name string) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), ".")); ext {
+	case media.SubtitleFormatSRT, media.SubtitleFormatWebVTT, media.SubtitleFormatASS, media.SubtitleFormatSSA:
+		return ext
+	}
+	return ""
+}
+
+// normalizeLanguage 统一语言标签的大小写: 语言小写、文字首字母大写、地区大写，例如 zh-hans-cn -> zh-Hans-CN
+func normalizeLanguage(language string) (string, bool) {
+	if !languagePattern.MatchString(language) {
+		return "", false
+	}
+	parts := strings.Split(language, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch {
+		case len(parts[i]) == 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		case len(parts[i]) == 2:
+			parts[i] = strings.ToUpper(parts[i])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// sanitizeSubtitleLabel 清理显示名称: 去掉控制字符和引号 (会写入播放列表的属性)，截断到 100 字节
+func sanitizeSubtitleLabel(label, fallback string) string {
+	var b strings.Builder
+	for _, r := range strings.ToValidUTF8(label, "") {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || r == '"' {
+			continue
+		}
+		if b.Len()+len(string(r)) > maxSubtitleLabelBytes {
+			break
+		}
+		b.WriteRune(r)
+	}
+	if name := strings.TrimSpace(b.String()); name != "" {
+		return name
+	}
+	return fallback
+}
+
+// UploadSubtitleService 校验字幕文件并转换为 WebVTT，保存到 subtitles/<id>/<language>.vtt (同一语言覆盖)，
+// 同时生成 HLS 字幕媒体播放列表并更新带字幕组的主播放列表
+func UploadSubtitleService(ctx context.Context, video *model.Video, upload SubtitleUpload) (*model.VideoSubtitle, error) {
+	language, ok := normalizeLanguage(upload.Language)
+	if !ok {
+		return nil, &UploadVerificationError{
+			Status:  http.StatusBadRequest,
+			Code:    "invalid_language",
+			Message: fmt.Sprintf("invalid language tag %q, expected a BCP 47 tag such as en or zh-CN", upload.Language),
+		}
+	}
+	if len(upload.Data) > MaxSubtitleBytes {
+		return nil, &UploadVerificationError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "file_too_large",
+			Message: fmt.Sprintf("subtitle file is %d bytes, the limit is %d bytes", len(upload.Data), MaxSubtitleBytes),
+		}
+	}
+	cues, err := media.ParseSubtitles(upload.Data, upload.Format)
+	if err != nil {
+		return nil, &UploadVerificationError{Status: http.StatusUnprocessableEntity, Code: "invalid_subtitles", Message: err.Error()}
+	}
+
+	vtt := media.BuildWebVTT(cues)
+	playlist := media.BuildSubtitlePlaylist(language+".vtt", subtitlePlaylistDuration(video, cues))
+
+	bucketName := config.AppConfig.MinIO.BucketName
+	prefix := subtitleObjectPrefix(video.ID)
+	subtitle := model.VideoSubtitle{
+		VideoID:      video.ID,
+		Language:     language,
+		Label:        sanitizeSubtitleLabel(upload.Label, language),
+		SourceFormat: upload.Format,
+		URL:          prefix + "/" + language + ".vtt",
+		PlaylistURL:  prefix + "/" + language + ".m3u8",
+		IsDefault:    upload.Default,
+		CueCount:     len(cues),
+	}
+	for objectName, content := range map[string]string{subtitle.URL: vtt, subtitle.PlaylistURL: playlist} {
+		contentType := "text/vtt; charset=utf-8"
+		if strings.HasSuffix(objectName, ".m3u8") {
+			contentType = "application/vnd.apple.mpegurl"
+		}
+		if _, err := dal.MinioClient.PutObject(ctx, bucketName, objectName, strings.NewReader(content), int64(len(content)),
+			minio.PutObjectOptions{ContentType: contentType}); err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", objectName, err)
+		}
+	}
+
+	err = dal.DB.Transaction(func(tx *gorm.DB) error {
+		// 最多一条默认字幕
+		if subtitle.IsDefault {
+			if err := tx.Model(&model.VideoSubtitle{}).Where("video_id = ? AND language <> ?", video.ID, language).
+				Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "video_id"}, {Name: "language"}},
+			DoUpdates: clause.AssignmentColumns([]string{"label", "source_format", "url", "playlist_url", "is_default", "cue_count", "updated_at"}),
+		}).Create(&subtitle).Error; err != nil {
+			return err
+		}
+		return tx.Where("video_id = ? AND language = ?", video.ID, language).First(&subtitle).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 字幕已经保存，主播放列表更新失败不影响上传结果: 详情接口只在主播放列表存在时才指向它，
+	// 下次上传、删除字幕或转码完成时会重新生成
+	if err := RebuildSubtitleMasterPlaylist(ctx, video.ID); err != nil {
+		log.Printf("Failed to update subtitle master playlist of video %d: %v", video.ID, err)
+	}
+	return &subtitle, nil
+}
+
+// DeleteSubtitleService 删除视频某种语言的字幕，并更新带字幕组的主播放列表
+func DeleteSubtitleService(ctx context.Context, video *model.Video, language string) error {
+	language, ok := normalizeLanguage(language)
+	if !ok {
+		return ErrSubtitleNotFound
+	}
+	var subtitle model.VideoSubtitle
+	if err := dal.DB.Where("video_id = ? AND language = ?", video.ID, language).First(&subtitle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubtitleNotFound
+		}
+		return err
+	}
+	if err := dal.DB.Delete(&subtitle).Error; err != nil {
+		return err
+	}
+
+	// 记录已经删除，主播放列表更新失败时只记录日志，并保留字幕文件，旧的主播放列表仍能正常播放
+	if err := RebuildSubtitleMasterPlaylist(ctx, video.ID); err != nil {
+		log.Printf("Failed to update subtitle master playlist of video %d: %v", video.ID, err)
+		return nil
+	}
+	bucketName := config.AppConfig.MinIO.BucketName
+	for _, objectName := range []string{subtitle.URL, subtitle.PlaylistURL} {
+		if err := dal.MinioClient.RemoveObject(ctx, bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+			log.Printf("Failed to remove subtitle object %s: %v", objectName, err)
+		}
+	}
+	return nil
+}
+
+// subtitlePlaylistDuration 返回字幕媒体播放列表的时长: 视频时长和最后一条字幕结束时间中较大的一个。
+// 转码前上传时视频时长未知 (为 0)，以最后一条字幕为准
+func subtitlePlaylistDuration(video *model.Video, cues []media.SubtitleCue) float64 {
+	duration := float64(video.Duration)
+	for _, c := range cues {
+		duration = max(duration, c.End.Seconds())
+	}
+	return duration
+}
+
+// RebuildSubtitlePlaylists 按视频的实际时长重新生成各语言的字幕媒体播放列表 (subtitles/<id>/<language>.m3u8)。
+// 转码完成前上传的字幕只能以最后一条字幕的结束时间作为时长，由 Worker 在转码结束后调用
+func RebuildSubtitlePlaylists(ctx context.Context, videoID uint64) error {
+	var video model.Video
+	if err := dal.DB.First(&video, videoID).Error; err != nil {
+		return err
+	}
+	var subtitles []model.VideoSubtitle
+	if err := dal.DB.Where("video_id = ?", videoID).Find(&subtitles).Error; err != nil {
+		return err
+	}
+
+	bucketName := config.AppConfig.MinIO.BucketName
+	for _, s := range subtitles {
+		obj, err := dal.MinioClient.GetObject(ctx, bucketName, s.URL, minio.GetObjectOptions{})
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(obj)
+		obj.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", s.URL, err)
+		}
+		cues, err := media.ParseSubtitles(data, media.SubtitleFormatWebVTT)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", s.URL, err)
+		}
+		playlist := media.BuildSubtitlePlaylist(path.Base(s.URL), subtitlePlaylistDuration(&video, cues))
+		if _, err := dal.MinioClient.PutObject(ctx, bucketName, s.PlaylistURL, strings.NewReader(playlist), int64(len(playlist)),
+			minio.PutObjectOptions{ContentType: "application/vnd.apple.mpegurl"}); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", s.PlaylistURL, err)
+		}
+	}
+	return nil
+}
+
+// ListSubtitlesService 返回视频的字幕轨道，URL 为带签名的临时地址
+func ListSubtitlesService(videoID uint64) ([]model.VideoSubtitle, error) {
+	var subtitles []model.VideoSubtitle
+	if err := dal.DB.Where("video_id = ?", videoID).Order("is_default desc, language").Find(&subtitles).Error; err != nil {
+		return nil, err
+	}
+	for i := range subtitles {
+		for _, objectURL := range []*string{&subtitles[i].URL, &subtitles[i].PlaylistURL} {
+			presignedURL, err := dal.MinioClient.PresignedGetObject(context.Background(),
+				config.AppConfig.MinIO.BucketName, *objectURL, time.Minute*15, make(url.Values))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate presigned url for subtitle %s: %w", *objectURL, err)
+			}
+			*objectURL = presignedURL.String()
+		}
+	}
+	return subtitles, nil
+}
+
+// RebuildSubtitleMasterPlaylist 重新生成 subtitles/<id>/master.m3u8: 复制转码产物中的自适应主播放列表，
+// 加入 EXT-X-MEDIA TYPE=SUBTITLES 字幕组。视频没有字幕时删除它；还没有转码完成时什么都不做，
+// 由 Worker 在转码结束后调用。
+func RebuildSubtitleMasterPlaylist(ctx context.Context, videoID uint64) error {
+	bucketName := config.AppConfig.MinIO.BucketName
+	masterKey := SubtitleMasterPlaylistKey(videoID)
+
+	var subtitles []model.VideoSubtitle
+	if err := dal.DB.Where("video_id = ?", videoID).Order("is_default desc, language").Find(&subtitles).Error; err != nil {
+		return err
+	}
+	if len(subtitles) == 0 {
+		err := dal.MinioClient.RemoveObject(ctx, bucketName, masterKey, minio.RemoveObjectOptions{})
+		if err != nil && minio.ToErrorResponse(err).Code != "NoSuchKey" {
+			return err
+		}
+		return nil
+	}
+
+	var auto model.VideoSource
+	err := dal.DB.Where("video_id = ? AND quality = ? AND format = ?", videoID, model.SourceQualityAuto, model.SourceFormatHLS).
+		First(&auto).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	obj, err := dal.MinioClient.GetObject(ctx, bucketName, auto.URL, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	original, err := io.ReadAll(obj)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", auto.URL, err)
+	}
+
+	renditions := make([]media.HLSSubtitle, 0, len(subtitles))
+	for _, s := range subtitles {
+		renditions = append(renditions, media.HLSSubtitle{
+			URI:      path.Base(s.PlaylistURL),
+			Language: s.Language,
+			Name:     s.Label,
+			Default:  s.IsDefault,
+		})
+	}
+	// 新的主播放列表位于 subtitles/<id>/，相对它引用转码产物目录中的媒体播放列表
+	baseURI := "../../" + path.Dir(auto.URL) + "/"
+	playlist := media.WithSubtitles(string(original), baseURI, renditions)
+	if _, err := dal.MinioClient.PutObject(ctx, bucketName, masterKey, strings.NewReader(playlist), int64(len(playlist)),
+		minio.PutObjectOptions{ContentType: "application/vnd.apple.mpegurl"}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// hasSubtitleMaster 判断视频是否有字幕轨道，且带字幕组的主播放列表已经生成。
+// 转码完成前上传的字幕、或者主播放列表生成失败时，对象不存在
+func hasSubtitleMaster(ctx context.Context, videoID uint64) (bool, error) {
+	var count int64
+	if err := dal.DB.Model(&model.VideoSubtitle{}).Where("video_id = ?", videoID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+	_, err := dal.MinioClient.StatObject(ctx, config.AppConfig.MinIO.BucketName, SubtitleMasterPlaylistKey(videoID), minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
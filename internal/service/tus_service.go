@@ -0,0 +1,186 @@
+// internal/service/tus_service.go
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTusUploadNotFound 上传不存在或已被终止
+	ErrTusUploadNotFound = errors.New("tus upload not found")
+	// ErrTusOffsetMismatch 客户端的 Upload-Offset 与服务端已持久化的字节数不一致
+	ErrTusOffsetMismatch = errors.New("upload offset does not match")
+	// ErrTusUploadTooLarge 写入的数据超出了创建时声明的 Upload-Length
+	ErrTusUploadTooLarge = errors.New("upload exceeds declared length")
+)
+
+// tusBufferPool 复用 PATCH 请求的分片缓冲区。缓冲区随收到的数据增长，
+// 小的 PATCH 不会预先分配整个分片大小 (16MB 以上) 的内存
+var tusBufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// tusTailObjectKey 返回暂存“不足一个分片”的尾部数据的对象路径。
+// tus 客户端每次 PATCH 的大小是任意的，而 S3 分片 (除最后一片) 至少 5MB，
+// 所以不足一个分片的数据先存成临时对象，下次 PATCH 时再拼接。
+func tusTailObjectKey(videoID uint64) string {
+	return fmt.Sprintf("tmp/tus/%d.part", videoID)
+}
+
+// CreateTusUploadService 处理 tus 的 creation 扩展: 创建视频记录和底层的分片上传会话
+func CreateTusUploadService(actor Actor, fileName, title, description string, length uint64) (*model.Video, error) {
+	if length == 0 {
+		return nil, errors.New("upload length must be greater than 0")
+	}
+	video, _, err := initiateUploadSession(actor, fileName, title, description, length, "tus")
+	return video, err
+}
+
+// GetTusUploadService 查询 tus 上传会话，用于响应 HEAD 请求
+func GetTusUploadService(videoID uint64) (*model.UploadSession, error) {
+	var session model.UploadSession
+	err := dal.DB.Where("video_id = ? AND protocol = ? AND status <> ?", videoID, "tus", "aborted").First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTusUploadNotFound
+	}
+	return &session, err
+}
+
+// WriteTusChunkService 处理一次 PATCH 请求: 从 offset 处开始写入 body，返回新的 offset。
+// 满一个分片就立即作为 multipart part 上传到 MinIO，剩余的尾部数据暂存为临时对象；
+// 当写满 Upload-Length 时合并所有分片，并执行与 CompleteUploadService 相同的状态流转。
+func WriteTusChunkService(videoID uint64, offset uint64, body io.Reader) (uint64, error) {
+	ctx := context.Background()
+	bucketName := config.AppConfig.MinIO.BucketName
+
+	session, err := GetTusUploadService(videoID)
+	if err != nil {
+		return 0, err
+	}
+	if session.Status != "active" {
+		return session.ReceivedBytes, ErrTusOffsetMismatch
+	}
+	if offset != session.ReceivedBytes {
+		return session.ReceivedBytes, ErrTusOffsetMismatch
+	}
+
+	// 1. 把上次暂存的尾部数据拼接在本次 body 之前
+	tailLen := session.ReceivedBytes % session.PartSize
+	committed := session.ReceivedBytes - tailLen // 已经作为完整分片上传的字节数
+	remaining := session.FileSize - session.ReceivedBytes
+	reader := io.LimitReader(body, int64(remaining)+1)
+	if tailLen > 0 && remaining > 0 {
+		tail, err := dal.MinioClient.GetObject(ctx, bucketName, tusTailObjectKey(videoID), minio.GetObjectOptions{})
+		if err != nil {
+			return session.ReceivedBytes, fmt.Errorf("failed to read pending tail: %w", err)
+		}
+		defer tail.Close()
+		reader = io.MultiReader(io.LimitReader(tail, int64(tailLen)), reader)
+	}
+
+	// 2. 按分片大小切分并上传
+	buf := tusBufferPool.Get().(*bytes.Buffer)
+	defer tusBufferPool.Put(buf)
+	received := committed
+	var readErr error
+	for {
+		buf.Reset()
+		copied, err := io.CopyN(buf, reader, int64(session.PartSize))
+		n := int(copied)
+		if n > 0 && received+uint64(n) > session.FileSize {
+			return session.ReceivedBytes, ErrTusUploadTooLarge
+		}
+		isLast := received+uint64(n) == session.FileSize
+		if uint64(n) == session.PartSize || (isLast && n > 0) {
+			partNumber := int(received/session.PartSize) + 1
+			if _, err := dal.MinioCore.PutObjectPart(ctx, bucketName, session.ObjectKey, session.UploadID,
+				partNumber, bytes.NewReader(buf.Bytes()), int64(n), minio.PutObjectPartOptions{}); err != nil {
+				return session.ReceivedBytes, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+			}
+			received += uint64(n)
+			if err := advanceTusOffset(session, received); err != nil {
+				return session.ReceivedBytes, err
+			}
+			if isLast {
+				break
+			}
+			continue
+		}
+
+		// 不足一个分片: 暂存为尾部对象，等待下一次 PATCH
+		if n > 0 {
+			if _, err := dal.MinioClient.PutObject(ctx, bucketName, tusTailObjectKey(videoID),
+				bytes.NewReader(buf.Bytes()), int64(n), minio.PutObjectOptions{}); err != nil {
+				return session.ReceivedBytes, fmt.Errorf("failed to store pending tail: %w", err)
+			}
+			if err := advanceTusOffset(session, received+uint64(n)); err != nil {
+				return session.ReceivedBytes, err
+			}
+		}
+		if err != nil && err != io.EOF {
+			// 客户端中途断开: 已经收到的数据已持久化，客户端可通过 HEAD 获取 offset 后续传
+			readErr = err
+		}
+		break
+	}
+	if readErr != nil {
+		return session.ReceivedBytes, readErr
+	}
+
+	// 3. 数据已全部到达: 清理尾部对象，合并分片并提交转码
+	if session.ReceivedBytes == session.FileSize {
+		if err := dal.MinioClient.RemoveObject(ctx, bucketName, tusTailObjectKey(videoID), minio.RemoveObjectOptions{}); err != nil {
+			log.Printf("Failed to remove tus tail object for video %d: %v", videoID, err)
+		}
+		if err := CompleteUploadService(videoID, ""); err != nil {
+			return session.ReceivedBytes, err
+		}
+	}
+
+	return session.ReceivedBytes, nil
+}
+
+// advanceTusOffset 以乐观锁的方式推进 offset，防止同一上传的并发 PATCH 互相覆盖
+func advanceTusOffset(session *model.UploadSession, newOffset uint64) error {
+	result := dal.DB.Model(&model.UploadSession{}).
+		Where("id = ? AND received_bytes = ?", session.ID, session.ReceivedBytes).
+		Update("received_bytes", newOffset)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTusOffsetMismatch
+	}
+	session.ReceivedBytes = newOffset
+	return nil
+}
+
+// TerminateTusUploadService 处理 tus 的 termination 扩展
+func TerminateTusUploadService(videoID uint64) error {
+	session, err := GetTusUploadService(videoID)
+	if err != nil {
+		return err
+	}
+	if session.Status != "active" {
+		// 已经完成的上传不能再终止
+		return ErrTusUploadNotFound
+	}
+	if err := AbortMultipartUploadService(videoID); err != nil {
+		return err
+	}
+	return dal.MinioClient.RemoveObject(context.Background(),
+		config.AppConfig.MinIO.BucketName,
+		tusTailObjectKey(videoID),
+		minio.RemoveObjectOptions{},
+	)
+}
@@ -0,0 +1,117 @@
+// internal/service/upload_event_service.go
+package service
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+// MinioEvent 是 MinIO 存储桶事件通知的消息体 (webhook 和 AMQP 目标格式相同)
+type MinioEvent struct {
+	EventName string             `json:"EventName"`
+	Key       string             `json:"Key"`
+	Records   []MinioEventRecord `json:"Records"`
+}
+
+// MinioEventRecord 是 S3 兼容的事件记录，这里只关心桶名和对象 key
+type MinioEventRecord struct {
+	EventName string `json:"eventName"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			Size int64  `json:"size"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// videoIDFromRawKey 从 raw/<video_id>/<file> 形式的对象 key 中解析出视频 ID
+func videoIDFromRawKey(key string) (uint64, bool) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 || parts[0] != "raw" || parts[2] == "" {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// HandleMinioEventService 处理 s3:ObjectCreated:* 事件，对 raw/ 下刚上传完成的原始文件自动执行“完成上传”。
+// 校验失败 (文件损坏、超出配额等) 已在 CompleteUploadService 中把视频标记为失败，这里只记录日志；
+// 只有数据库、MinIO、RabbitMQ 等临时错误才返回 error，便于调用方让 MinIO / RabbitMQ 重新投递。
+func HandleMinioEventService(event MinioEvent) error {
+	var errs []error
+	for _, record := range event.Records {
+		if !strings.HasPrefix(record.EventName, "s3:ObjectCreated:") {
+			continue
+		}
+		if record.S3.Bucket.Name != config.AppConfig.MinIO.BucketName {
+			continue
+		}
+		// 事件中的 key 经过 URL 编码
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			log.Printf("Ignoring MinIO event with malformed key %q: %v", record.S3.Object.Key, err)
+			continue
+		}
+		videoID, ok := videoIDFromRawKey(key)
+		if !ok {
+			continue
+		}
+		if err := completeUploadFromEvent(videoID, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// completeUploadFromEvent 对单个对象事件执行完成上传，跳过与视频不匹配或不需要处理的对象
+func completeUploadFromEvent(videoID uint64, key string) error {
+	var video model.Video
+	if err := dal.DB.First(&video, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Ignoring MinIO event for %s: video %d not found", key, videoID)
+			return nil
+		}
+		return err
+	}
+	if video.Status != "uploading" || video.RawObjectKey() != key {
+		return nil
+	}
+
+	// 仍有进行中的分片 / tus 会话时，对象由服务端在完成会话时合并，完成逻辑由会话自己触发
+	var activeSessions int64
+	if err := dal.DB.Model(&model.UploadSession{}).
+		Where("video_id = ? AND status = ?", videoID, "active").
+		Count(&activeSessions).Error; err != nil {
+		return err
+	}
+	if activeSessions > 0 {
+		return nil
+	}
+
+	err := CompleteUploadService(videoID, "")
+	if err == nil {
+		log.Printf("Upload of video %d completed by MinIO event", videoID)
+		return nil
+	}
+	var verr *UploadVerificationError
+	var qerr *QuotaExceededError
+	if errors.As(err, &verr) || errors.As(err, &qerr) {
+		log.Printf("Upload of video %d rejected on MinIO event: %v", videoID, err)
+		return nil
+	}
+	return err
+}
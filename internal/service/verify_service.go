@@ -0,0 +1,164 @@
+// internal/service/verify_service.go
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+)
+
+// UploadVerificationError 表示上传的原始文件没有通过服务端校验。
+// Status 是建议返回给客户端的 HTTP 状态码，Code 是便于客户端判断的错误码。
+type UploadVerificationError struct {
+	Status  int
+	Code    string
+	Message string
+	// Retryable 为 true 表示客户端可以修正后再次调用“完成上传” (例如文件还没传完)
+	Retryable bool
+}
+
+func (e *UploadVerificationError) Error() string {
+	return e.Message
+}
+
+// contentTypeAllowed 判断 Content-Type 是否命中配置的前缀白名单
+func contentTypeAllowed(contentType string) bool {
+	allowed := config.AppConfig.Upload.AllowedContentTypes
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, prefix := range allowed {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyRawObject 在提交转码前校验 MinIO 中的原始文件:
+// 对象是否存在、大小是否超限、Content-Type 是否允许、SHA-256 是否一致，以及 ffprobe 能否正常解析。
+// 校验通过时同时返回对象大小，由调用方记录到 videos.raw_size 用于配额统计
+func verifyRawObject(ctx context.Context, video *model.Video, expectedSHA256 string) (*media.ProbeResult, int64, error) {
+	bucketName := config.AppConfig.MinIO.BucketName
+	objectKey := video.RawObjectKey()
+	if objectKey == "" {
+		return nil, 0, fmt.Errorf("video %d has no object key, run cmd/migrate-keys first", video.ID)
+	}
+
+	// 1. 对象是否存在
+	info, err := dal.MinioClient.StatObject(ctx, bucketName, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, 0, &UploadVerificationError{
+				Status:    http.StatusConflict,
+				Code:      "object_not_found",
+				Message:   fmt.Sprintf("uploaded file %s not found, the upload may not have finished yet", video.OriginalFileName),
+				Retryable: true,
+			}
+		}
+		return nil, 0, fmt.Errorf("failed to stat uploaded object: %w", err)
+	}
+
+	// 2. 大小
+	if info.Size == 0 {
+		return nil, 0, &UploadVerificationError{Status: http.StatusUnprocessableEntity, Code: "empty_file", Message: "uploaded file is empty"}
+	}
+	if maxMB := config.AppConfig.Upload.MaxFileSizeMB; maxMB > 0 && info.Size > maxMB<<20 {
+		return nil, 0, &UploadVerificationError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Code:    "file_too_large",
+			Message: fmt.Sprintf("uploaded file is %d bytes, the limit is %d MB", info.Size, maxMB),
+		}
+	}
+
+	// 3. 客户端上传时声明的 Content-Type，未声明时根据扩展名推断
+	contentType := info.ContentType
+	if contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		contentType = mime.TypeByExtension(path.Ext(objectKey))
+	}
+	if !contentTypeAllowed(contentType) {
+		return nil, 0, &UploadVerificationError{
+			Status:  http.StatusUnsupportedMediaType,
+			Code:    "unsupported_content_type",
+			Message: fmt.Sprintf("content type %q is not allowed", contentType),
+		}
+	}
+
+	// 4. 可选的 SHA-256 校验
+	if expectedSHA256 != "" {
+		obj, err := dal.MinioClient.GetObject(ctx, bucketName, objectKey, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read uploaded object: %w", err)
+		}
+		hasher := sha256.New()
+		_, err = io.Copy(hasher, obj)
+		obj.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to hash uploaded object: %w", err)
+		}
+		if actual := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(actual, expectedSHA256) {
+			return nil, 0, &UploadVerificationError{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    "checksum_mismatch",
+				Message: fmt.Sprintf("sha256 mismatch: expected %s, got %s", expectedSHA256, actual),
+			}
+		}
+	}
+
+	// 5. ffprobe 快速检查: 直接读取预签名 URL，不需要把文件下载到 API 服务器
+	timeout := time.Duration(config.AppConfig.Upload.ProbeTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	presignedURL, err := dal.MinioClient.PresignedGetObject(ctx, bucketName, objectKey, timeout+time.Minute, make(url.Values))
+	if err != nil {
+		return nil, 0, err
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	probe, err := media.Probe(probeCtx, presignedURL.String())
+	if err != nil {
+		// 只有 ffprobe 读到了文件并拒绝它时才是无效文件；超时、ffprobe 无法启动、连不上 MinIO 等
+		// 暂时性问题不能让有效的上传永久失败，客户端 (或 Worker) 稍后可以再次完成上传
+		var perr *media.ProbeError
+		if !errors.As(err, &perr) || perr.NetworkError() {
+			log.Printf("Failed to probe uploaded object %s: %v", objectKey, err)
+			return nil, 0, &UploadVerificationError{
+				Status:    http.StatusServiceUnavailable,
+				Code:      "probe_unavailable",
+				Message:   "the uploaded file could not be checked right now, please try again later",
+				Retryable: true,
+			}
+		}
+		return nil, 0, &UploadVerificationError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "invalid_media",
+			Message: "uploaded file is not a readable media file: " + err.Error(),
+		}
+	}
+	if !probe.HasStream("video") && !probe.HasStream("audio") {
+		return nil, 0, &UploadVerificationError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    "invalid_media",
+			Message: "uploaded file contains no audio or video stream",
+		}
+	}
+
+	return probe, info.Size, nil
+}
@@ -3,12 +3,13 @@ package service
 
 import (
 	"context"
-	"path/filepath"
+	"errors"
 	"time"
 
 	"github.com/cjh/video-platform-go/internal/config"
 	"github.com/cjh/video-platform-go/internal/dal"
 	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
 
 	// "github.com/minio/minio-go/v7" - Unused import
 	"encoding/json" // 确保导入
@@ -22,21 +23,66 @@ type TranscodeTaskPayload struct {
 }
 
 // CompleteUploadService 处理“完成上传”的逻辑
-func CompleteUploadService(videoID uint64) error {
+// expectedSHA256 为客户端可选提供的文件摘要 (十六进制)，为空时跳过摘要校验。
+// 该函数是幂等的: 客户端显式调用和 MinIO 事件通知可能先后 (甚至同时) 触发，
+// 只有第一个把状态从 uploading 改为 transcoding 的调用会发布转码任务，
+// 视频已经进入转码或上线时直接返回 nil (此时不再做摘要校验)。
+func CompleteUploadService(videoID uint64, expectedSHA256 string) error {
 	// 1. 验证视频是否存在且状态正确
 	var video model.Video
 	if err := dal.DB.First(&video, videoID).Error; err != nil {
 		return fmt.Errorf("video with id %d not found", videoID)
 	}
-	if video.Status != "uploading" {
+	switch video.Status {
+	case "uploading":
+	case "transcoding", "online":
+		return nil
+	default:
 		return fmt.Errorf("video status is not 'uploading'")
 	}
 
-	// 2. 更新视频状态为 'transcoding'（准备中）
-	if err := dal.DB.Model(&video).Update("status", "transcoding").Error; err != nil {
+	// 1.1 如果是分片上传，先让 MinIO 把所有已上传的分片合并成完整对象
+	var session model.UploadSession
+	err := dal.DB.Where("video_id = ? AND status = ?", videoID, "active").First(&session).Error
+	if err == nil {
+		if err := completeMultipartSession(context.Background(), &session); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// 1.2 服务端校验原始文件，避免把注定失败的任务放进队列
+	probe, rawSize, err := verifyRawObject(context.Background(), &video, expectedSHA256)
+	if err != nil {
+		var verr *UploadVerificationError
+		if errors.As(err, &verr) && !verr.Retryable {
+			markUploadFailed(videoID)
+		}
 		return err
 	}
 
+	// 1.3 按视频所有者的配额检查存储和时长
+	if err := checkCompleteQuota(&video, probe.DurationSeconds(), uint64(rawSize)); err != nil {
+		var qerr *QuotaExceededError
+		if errors.As(err, &qerr) {
+			markUploadFailed(videoID)
+		}
+		return err
+	}
+
+	// 2. 更新视频状态为 'transcoding'（准备中），同时记录原始文件大小用于配额统计
+	// 条件更新保证并发的完成请求只有一个会继续发布任务
+	result := dal.DB.Model(&model.Video{}).
+		Where("id = ? AND status = ?", videoID, "uploading").
+		Updates(map[string]interface{}{"status": "transcoding", "raw_size": rawSize})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
 	// 3. 创建任务并发送到 RabbitMQ
 	task := TranscodeTaskPayload{VideoID: videoID}
 	body, err := json.Marshal(task)
@@ -49,38 +95,59 @@ func CompleteUploadService(videoID uint64) error {
 	return dal.PublishTranscodeTask(context.Background(), body)
 }
 
-// 新签名：InitiateUploadService(userID uint64, fileName, title, description string)
+// markUploadFailed 把仍处于 uploading 的视频标记为失败，不会覆盖其他调用已经推进的状态
+func markUploadFailed(videoID uint64) {
+	dal.DB.Model(&model.Video{}).
+		Where("id = ? AND status = ?", videoID, "uploading").
+		Update("status", "failed")
+}
+
+// uploadPresignExpiry 返回预签名上传链接的有效期，未配置时默认 24 小时
+func uploadPresignExpiry() time.Duration {
+	if hours := config.AppConfig.Upload.PresignExpireHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return time.Hour * 24
+}
+
+// 新签名：InitiateUploadService(actor Actor, fileName, title, description string, fileSize uint64)
+// fileSize 为客户端声明的文件大小 (可为 0)，用于提前检查存储配额
 func InitiateUploadService(
-	userID uint64,
+	actor Actor,
 	fileName string,
 	title string,
 	description string,
+	fileSize uint64,
 ) (string, *model.Video, error) {
 
+	// 0. 检查配额 (当日上传数量、存储空间)
+	if err := checkInitiateQuota(actor, fileSize); err != nil {
+		return "", nil, err
+	}
+
 	// 1. 创建视频记录，并保存原始文件名
 	video := model.Video{
-		UserID:           userID,
+		UserID:           actor.UserID,
 		Title:            title,
 		Description:      description,
-		OriginalFileName: fileName, // <-- 将传入的 fileName 保存到新字段
+		OriginalFileName: fileName, // 仅用于展示，会先经过 SanitizeDisplayName 清理
 		Status:           "uploading",
 	}
-	if err := dal.DB.Create(&video).Error; err != nil {
+	if err := createUploadVideo(dal.DB, &video); err != nil {
 		// 如果创建失败，可能需要记录日志
 		return "", nil, err
 	}
 
-	// 2. 使用原始文件名(fileName)和新生成的video.ID来构建对象路径
-	objectName := filepath.Join("raw", fmt.Sprintf("%d", video.ID), fileName)
+	// 2. 对象路径由服务端生成，不使用客户端提供的文件名
+	objectName := video.RawObjectKey()
 	bucketName := config.AppConfig.MinIO.BucketName
-	expiration := time.Hour * 24 // 上传链接有效期24小时
 
 	// 3. 生成预签名 PUT URL
 	urlObj, err := dal.MinioClient.PresignedPutObject(
 		context.Background(),
 		bucketName,
 		objectName,
-		expiration,
+		uploadPresignExpiry(),
 	)
 	if err != nil {
 		// 如果生成URL失败，最好将刚才创建的数据库记录删除或标记为失败，以避免脏数据
@@ -141,9 +208,21 @@ func GetVideoDetailsService(videoID uint64) (*model.Video, []model.VideoSource,
 	}
 
 	var sources []model.VideoSource
-	if err := dal.DB.Where("video_id = ?", videoID).Find(&sources).Error; err != nil {
+	// 同一清晰度的 HLS 和 DASH 排在一起，客户端按 format 选择
+	if err := dal.DB.Where("video_id = ?", videoID).Order("id").Find(&sources).Error; err != nil {
+		return nil, nil, err
+	}
+
+	// 有字幕且带字幕组的主播放列表已经生成时，自适应主播放列表换成那一份
+	withSubtitles, err := hasSubtitleMaster(context.Background(), videoID)
+	if err != nil {
 		return nil, nil, err
 	}
+	for i := range sources {
+		if withSubtitles && sources[i].Quality == model.SourceQualityAuto && sources[i].Format == model.SourceFormatHLS {
+			sources[i].URL = SubtitleMasterPlaylistKey(videoID)
+		}
+	}
 
 	// 为每个播放源生成带签名的临时 URL
 	for i := range sources {
@@ -163,5 +242,41 @@ func GetVideoDetailsService(videoID uint64) (*model.Video, []model.VideoSource,
 		sources[i].URL = presignedURL.String()
 	}
 
+	// 预览图轨道同样签名；VTT 中的雪碧图使用相对路径，与播放列表中的分片一样依赖桶的公开读权限
+	if video.ThumbnailVTTURL != "" {
+		presignedURL, err := dal.MinioClient.PresignedGetObject(context.Background(),
+			config.AppConfig.MinIO.BucketName, video.ThumbnailVTTURL, time.Minute*15, make(url.Values))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate presigned url for thumbnails %s: %w", video.ThumbnailVTTURL, err)
+		}
+		video.ThumbnailVTTURL = presignedURL.String()
+	}
+
+	// 纯音频的波形数据
+	if video.WaveformURL != "" {
+		presignedURL, err := dal.MinioClient.PresignedGetObject(context.Background(),
+			config.AppConfig.MinIO.BucketName, video.WaveformURL, time.Minute*15, make(url.Values))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate presigned url for waveform %s: %w", video.WaveformURL, err)
+		}
+		video.WaveformURL = presignedURL.String()
+	}
+
 	return &video, sources, nil
 }
+
+// PrimaryPlaybackURL 返回默认播放地址: 优先使用自适应码率的 auto 主播放列表，
+// 旧视频没有 auto 播放源时退回到第一个 HLS 播放源，都没有时返回空字符串
+func PrimaryPlaybackURL(sources []model.VideoSource) string {
+	for _, s := range sources {
+		if s.Quality == model.SourceQualityAuto {
+			return s.URL
+		}
+	}
+	for _, s := range sources {
+		if s.Format == model.SourceFormatHLS {
+			return s.URL
+		}
+	}
+	return ""
+}
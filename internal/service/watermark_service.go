@@ -0,0 +1,142 @@
+// internal/service/watermark_service.go
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Watermark 是某个上传者生效的水印: 平台配置合并 user_watermarks 中的个人覆盖
+type Watermark struct {
+	Image    string  `json:"image"    example:"branding/logo.png"` // 水印图片的对象路径
+	Position string  `json:"position" example:"bottom-right"`
+	Margin   float64 `json:"margin"   example:"0.03"` // 到画面边缘的距离，相对输出高度
+	Opacity  float64 `json:"opacity"  example:"0.8"`
+	Scale    float64 `json:"scale"    example:"0.08"` // 水印高度相对输出高度
+}
+
+// Fingerprint 返回水印的指纹，imageETag 为水印图片对象的 ETag。图片内容或样式变化后指纹随之变化，
+// 用于判断转码产物能否在视频之间共享
+func (w *Watermark) Fingerprint(imageETag string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%g\x00%g\x00%g",
+		w.Image, imageETag, w.Position, w.Margin, w.Opacity, w.Scale)))
+	return hex.EncodeToString(sum[:])
+}
+
+// WatermarkSettings 是上传者的水印设置
+type WatermarkSettings struct {
+	Enabled   bool       `json:"enabled"     example:"true"`  // 平台是否启用了水印
+	CanOptOut bool       `json:"can_opt_out" example:"false"` // 当前角色能否关闭水印
+	OptOut    bool       `json:"opt_out"     example:"false"` // 是否已关闭水印 (角色不允许时不生效)
+	Watermark *Watermark `json:"watermark"`                   // 之后转码的视频实际叠加的水印，不叠加时为 null
+}
+
+// CanOptOutWatermark 判断角色能否为自己的视频关闭水印
+func CanOptOutWatermark(role string) bool {
+	return slices.Contains(config.AppConfig.Watermark.OptOutRoles, role)
+}
+
+// loadUserWatermark 读取用户的个人水印设置，没有时返回 nil
+func loadUserWatermark(userID uint64) (*model.UserWatermark, error) {
+	var override model.UserWatermark
+	err := dal.DB.First(&override, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// resolveWatermark 合并平台配置和个人覆盖，平台未启用或上传者已关闭水印时返回 nil
+func resolveWatermark(role string, override *model.UserWatermark) (*Watermark, error) {
+	cfg := config.AppConfig.Watermark
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	w := &Watermark{
+		Image:    cfg.Image,
+		Position: cfg.Position,
+		Margin:   cfg.Margin,
+		Opacity:  cfg.Opacity,
+		Scale:    cfg.Scale,
+	}
+	if override != nil {
+		if override.OptOut && CanOptOutWatermark(role) {
+			return nil, nil
+		}
+		if override.Image != nil && *override.Image != "" {
+			w.Image = *override.Image
+		}
+		if override.Position != nil {
+			w.Position = *override.Position
+		}
+		if override.Margin != nil {
+			w.Margin = *override.Margin
+		}
+		if override.Opacity != nil {
+			w.Opacity = *override.Opacity
+		}
+		if override.Scale != nil {
+			w.Scale = *override.Scale
+		}
+		if err := config.ValidateWatermarkStyle(w.Position, w.Margin, w.Opacity, w.Scale); err != nil {
+			return nil, fmt.Errorf("invalid user_watermarks entry for user %d: %w", override.UserID, err)
+		}
+	}
+	return w, nil
+}
+
+// ResolveWatermark 返回上传者的视频转码时应叠加的水印，不叠加时返回 nil。
+// role 取自数据库中的当前角色，角色变化后关闭水印的设置可能不再生效
+func ResolveWatermark(userID uint64, role string) (*Watermark, error) {
+	override, err := loadUserWatermark(userID)
+	if err != nil {
+		return nil, err
+	}
+	return resolveWatermark(role, override)
+}
+
+// GetWatermarkSettingsService 返回当前用户的水印设置
+func GetWatermarkSettingsService(actor Actor) (*WatermarkSettings, error) {
+	override, err := loadUserWatermark(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	watermark, err := resolveWatermark(actor.Role, override)
+	if err != nil {
+		return nil, err
+	}
+	return &WatermarkSettings{
+		Enabled:   config.AppConfig.Watermark.Enabled,
+		CanOptOut: CanOptOutWatermark(actor.Role),
+		OptOut:    override != nil && override.OptOut,
+		Watermark: watermark,
+	}, nil
+}
+
+// SetWatermarkOptOutService 为当前用户之后转码的视频关闭或恢复水印，角色不允许关闭时返回 ErrForbidden。
+// 已经转码完成的视频不受影响
+func SetWatermarkOptOutService(actor Actor, optOut bool) (*WatermarkSettings, error) {
+	if optOut && !CanOptOutWatermark(actor.Role) {
+		return nil, fmt.Errorf("%w: role %s cannot opt out of the watermark", ErrForbidden, actor.Role)
+	}
+	record := model.UserWatermark{UserID: actor.UserID, OptOut: optOut}
+	if err := dal.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"opt_out", "updated_at"}),
+	}).Create(&record).Error; err != nil {
+		return nil, err
+	}
+	return GetWatermarkSettingsService(actor)
+}
@@ -0,0 +1,172 @@
+// internal/worker/audio.go
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+)
+
+// waveformSampleRate 是计算波形时的采样率，只用于显示，8 kHz 足够
+const waveformSampleRate = 8000
+
+// rendition 是一次转码输出的一个档位: 视频档位或纯音频的 AAC 档位
+type rendition struct {
+	Name string
+	Args func(input, outputDir string) []string
+}
+
+// videoRenditions 把视频档位转为 rendition
+func videoRenditions(profiles []config.Profile, opts transcodeOptions) []rendition {
+	renditions := make([]rendition, 0, len(profiles))
+	for _, p := range profiles {
+		renditions = append(renditions, rendition{
+			Name: p.Name,
+			Args: func(input, outputDir string) []string { return transcodeArgs(p, input, outputDir, opts) },
+		})
+	}
+	return renditions
+}
+
+// audioRenditions 把纯音频档位转为 rendition，audioFilter 为响度标准化滤镜 (可以为空)，enc 为 HLS 加密 (可以为 nil)
+func audioRenditions(profiles []config.AudioProfile, audioFilter string, enc *hlsEncryption) []rendition {
+	renditions := make([]rendition, 0, len(profiles))
+	for _, p := range profiles {
+		renditions = append(renditions, rendition{
+			Name: p.Name,
+			Args: func(input, outputDir string) []string {
+				return audioTranscodeArgs(p, input, outputDir, audioFilter, enc)
+			},
+		})
+	}
+	return renditions
+}
+
+// audioTranscodeArgs 生成纯音频档位的 ffmpeg 参数: 只取第一个音频流编码为 AAC，打包方式与视频档位相同，
+// 音频流为 media_0.m3u8。内嵌的专辑封面 (attached_pic) 不会被输出。
+func audioTranscodeArgs(profile config.AudioProfile, input, outputDir, audioFilter string, enc *hlsEncryption) []string {
+	args := []string{"-i", input, "-map", "0:a:0", "-vn"}
+	if audioFilter != "" {
+		args = append(args, "-af", audioFilter)
+	}
+	args = append(args, "-c:a", "aac", "-b:a", profile.AudioBitrate, "-ac", "2")
+	if profile.SampleRate > 0 {
+		args = append(args, "-ar", strconv.Itoa(profile.SampleRate))
+	}
+	return append(args, packagingArgs(profile.SegmentSeconds, outputDir, enc)...)
+}
+
+// generateWaveform 把音频混为单声道 PCM，计算 ffmpeg.waveform.points 个点的波形并上传为
+// processed/<id>/waveform.json，返回其对象路径
+func generateWaveform(ctx context.Context, bucketName string, videoID uint64, input string, duration float64) (string, error) {
+	objectName := fmt.Sprintf("processed/%d/waveform.json", videoID)
+	if objectExists(ctx, bucketName, objectName) {
+		return objectName, nil
+	}
+
+	// 每个点覆盖的采样数由时长决定，时长未知时按 1 秒一个点
+	samplesPerPixel := waveformSampleRate
+	if points := config.AppConfig.FFMpeg.Waveform.Points; duration > 0 {
+		samplesPerPixel = max(1, int(math.Ceil(duration*waveformSampleRate/float64(points))))
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", input, "-map", "0:a:0", "-vn",
+		"-ac", "1", "-ar", strconv.Itoa(waveformSampleRate), "-f", "s16le", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	waveform, err := media.ComputeWaveform(stdout, waveformSampleRate, samplesPerPixel)
+	if waitErr := cmd.Wait(); waitErr != nil {
+		return "", fmt.Errorf("ffmpeg failed to decode audio: %w: %s", waitErr, stderr.String())
+	}
+	if err != nil {
+		return "", err
+	}
+	if waveform.Length == 0 {
+		return "", fmt.Errorf("no audio samples decoded")
+	}
+
+	data, err := json.Marshal(waveform)
+	if err != nil {
+		return "", err
+	}
+	if _, err := dal.MinioClient.PutObject(ctx, bucketName, objectName, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: "application/json"}); err != nil {
+		return "", fmt.Errorf("failed to upload waveform: %w", err)
+	}
+	return objectName, nil
+}
+
+// generateAudioCovers 为纯音频生成候选封面: 有内嵌专辑封面时排在第一张，其后是整段音频的波形图。
+// 上传到 processed/<id>/covers/，与视频的候选封面一样可以在封面接口中选择。
+func generateAudioCovers(ctx context.Context, bucketName string, videoID uint64, input, tempDir string, hasAttachedPic bool) ([]model.VideoCoverCandidate, error) {
+	cfg := config.AppConfig.Cover
+	outputDir := filepath.Join(tempDir, "covers")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		return nil, err
+	}
+	scale := fmt.Sprintf("scale=w='min(%d,iw)':h='min(%d,ih)':force_original_aspect_ratio=decrease", cfg.MaxWidth, cfg.MaxHeight)
+
+	var commands [][]string
+	if hasAttachedPic {
+		commands = append(commands, []string{"-i", input, "-map", "0:v:0", "-vf", scale,
+			"-frames:v", "1", "-q:v", "2", "-y", filepath.Join(outputDir, "album-art.jpg")})
+	}
+	// 16:9 的波形图，宽度不超过封面上限
+	width := cfg.MaxWidth &^ 1
+	height := min(cfg.MaxHeight, width*9/16) &^ 1
+	commands = append(commands, []string{"-i", input, "-filter_complex",
+		fmt.Sprintf("[0:a:0]aformat=channel_layouts=mono,showwavespic=s=%dx%d:colors=0x4a90e2", width, height),
+		"-frames:v", "1", "-q:v", "2", "-y", filepath.Join(outputDir, "waveform.jpg")})
+
+	var candidates []model.VideoCoverCandidate
+	for _, args := range commands {
+		outputPath := args[len(args)-1]
+		if output, err := exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput(); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to generate cover %s for video %d: %v: %s", filepath.Base(outputPath), videoID, err, string(output))
+			continue
+		}
+		brightness, err := imageBrightness(outputPath)
+		if err != nil {
+			log.Printf("Failed to read cover %s: %v", outputPath, err)
+			continue
+		}
+
+		objectName := fmt.Sprintf("processed/%d/covers/%s", videoID, filepath.Base(outputPath))
+		if _, err := dal.MinioClient.FPutObject(ctx, bucketName, objectName, outputPath,
+			minio.PutObjectOptions{ContentType: "image/jpeg"}); err != nil {
+			return nil, fmt.Errorf("failed to upload cover %s: %w", objectName, err)
+		}
+		candidates = append(candidates, model.VideoCoverCandidate{
+			VideoID:    videoID,
+			URL:        objectName,
+			Brightness: brightness,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no cover could be generated")
+	}
+	return candidates, nil
+}
@@ -0,0 +1,107 @@
+// internal/worker/checkpoint.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// profileCheckpoint 是上一次执行中已经完成的档位: 播放源记录已写入，对象仍在 MinIO 中
+type profileCheckpoint struct {
+	Sources []model.VideoSource
+	// Variant 用于重新生成主播放列表，上次没能读取码流属性时为 nil
+	Variant *media.HLSVariant
+}
+
+// upsertVideoSources 写入播放源，(video_id, quality, format) 已存在时覆盖，任务重复执行不会违反唯一索引
+func upsertVideoSources(db *gorm.DB, sources []model.VideoSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "video_id"}, {Name: "quality"}, {Name: "format"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "file_size", "bandwidth", "width", "height", "codecs", "frame_rate", "watermark_key"}),
+	}).Create(&sources).Error
+}
+
+// loadProfileCheckpoints 读取视频已经完成的档位。每个档位上传完所有文件后才写入播放源记录，
+// 所以 HLS 和 DASH 记录都存在、且播放列表对象还在时，即可跳过该档位的转码。
+// 加密的档位只有 HLS 记录，且必须位于 hls_<name> 目录 (使用视频当前的内容密钥)。
+// 档位叠加的水印 (watermark_key) 与本次不同时 (平台或上传者修改了水印)，同样重新转码。
+// 档位按名称匹配，修改同名档位的编码参数后需要重新上传视频才会生效。
+func loadProfileCheckpoints(ctx context.Context, bucketName string, videoID uint64, encrypted bool, watermarkKey string) (map[string]*profileCheckpoint, error) {
+	var sources []model.VideoSource
+	if err := dal.DB.Where("video_id = ? AND quality <> ?", videoID, model.SourceQualityAuto).Find(&sources).Error; err != nil {
+		return nil, err
+	}
+
+	byQuality := make(map[string]*profileCheckpoint)
+	for _, s := range sources {
+		cp, ok := byQuality[s.Quality]
+		if !ok {
+			cp = &profileCheckpoint{}
+			byQuality[s.Quality] = cp
+		}
+		cp.Sources = append(cp.Sources, s)
+	}
+
+	checkpoints := make(map[string]*profileCheckpoint)
+	for quality, cp := range byQuality {
+		var hls *model.VideoSource
+		formats := make(map[string]bool)
+		complete := true
+		for i := range cp.Sources {
+			s := &cp.Sources[i]
+			formats[s.Format] = true
+			if s.Format == model.SourceFormatHLS {
+				hls = s
+			}
+			if s.WatermarkKey != watermarkKey || !objectExists(ctx, bucketName, s.URL) {
+				complete = false
+			}
+		}
+		if !complete || hls == nil || path.Base(path.Dir(hls.URL)) != profileDir(quality, encrypted) {
+			continue
+		}
+		if !encrypted && !formats[model.SourceFormatDASH] {
+			continue
+		}
+
+		if hls.Bandwidth > 0 {
+			// 与 describeCMAFVariant 的输出一致: URI 相对 processed/<id>/
+			dir := path.Dir(hls.URL)
+			cp.Variant = &media.HLSVariant{
+				URI:       path.Join(path.Base(dir), "media_0.m3u8"),
+				Bandwidth: hls.Bandwidth,
+				Width:     hls.Width,
+				Height:    hls.Height,
+				Codecs:    hls.Codecs,
+				FrameRate: hls.FrameRate,
+			}
+			if objectExists(ctx, bucketName, path.Join(dir, "media_1.m3u8")) {
+				cp.Variant.AudioURI = path.Join(path.Base(dir), "media_1.m3u8")
+			}
+		}
+		checkpoints[quality] = cp
+	}
+	return checkpoints, nil
+}
+
+// objectExists 判断对象是否存在，查询出错时按不存在处理 (重新转码)
+func objectExists(ctx context.Context, bucketName, objectName string) bool {
+	_, err := dal.MinioClient.StatObject(ctx, bucketName, objectName, minio.StatObjectOptions{})
+	return err == nil
+}
+
+// profileObjectPrefix 返回档位在 MinIO 中的目录，dir 为 profileDir 的结果
+func profileObjectPrefix(videoID uint64, dir string) string {
+	return fmt.Sprintf("processed/%d/%s", videoID, dir)
+}
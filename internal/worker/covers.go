@@ -0,0 +1,109 @@
+// internal/worker/covers.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"image/jpeg"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+)
+
+// thumbnailFilterFrames 是 ffmpeg thumbnail 滤镜的分析窗口: 从截取位置开始的这么多帧中选出最有代表性的一帧
+const thumbnailFilterFrames = 50
+
+// generateCoverCandidates 在视频中均匀截取 cover.candidates 张候选封面，丢弃黑帧后上传到 processed/<id>/covers/。
+// 全部是黑帧时保留最亮的一张，保证视频总有封面。返回的记录按截取位置排序，第一张作为默认封面。
+func generateCoverCandidates(ctx context.Context, bucketName string, videoID uint64, input, tempDir string, duration float64) ([]model.VideoCoverCandidate, error) {
+	cfg := config.AppConfig.Cover
+	outputDir := filepath.Join(tempDir, "covers")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		return nil, err
+	}
+
+	type frame struct {
+		path       string
+		offset     float64
+		brightness float64
+	}
+	var frames []frame
+	for i := 0; i < cfg.Candidates; i++ {
+		// 避开片头和片尾，在 (0, duration) 中均匀取点
+		offset := duration * float64(i+1) / float64(cfg.Candidates+1)
+		outputPath := filepath.Join(outputDir, fmt.Sprintf("candidate-%d.jpg", i+1))
+		filter := fmt.Sprintf("thumbnail=%d,scale=w='min(%d,iw)':h='min(%d,ih)':force_original_aspect_ratio=decrease",
+			thumbnailFilterFrames, cfg.MaxWidth, cfg.MaxHeight)
+		cmd := exec.CommandContext(ctx, "ffmpeg", "-ss", strconv.FormatFloat(offset, 'f', 3, 64), "-i", input,
+			"-map", "0:v:0", "-vf", filter, "-frames:v", "1", "-q:v", "2", "-y", outputPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Failed to extract cover candidate at %.3fs of video %d: %v: %s", offset, videoID, err, string(output))
+			continue
+		}
+
+		brightness, err := imageBrightness(outputPath)
+		if err != nil {
+			log.Printf("Failed to read cover candidate %s: %v", outputPath, err)
+			continue
+		}
+		frames = append(frames, frame{path: outputPath, offset: offset, brightness: brightness})
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no cover candidate could be extracted")
+	}
+
+	var kept []frame
+	brightest := frames[0]
+	for _, f := range frames {
+		if f.brightness >= cfg.BlackThreshold {
+			kept = append(kept, f)
+		}
+		if f.brightness > brightest.brightness {
+			brightest = f
+		}
+	}
+	if len(kept) == 0 {
+		kept = []frame{brightest}
+	}
+
+	candidates := make([]model.VideoCoverCandidate, 0, len(kept))
+	for _, f := range kept {
+		objectName := fmt.Sprintf("processed/%d/covers/%s", videoID, filepath.Base(f.path))
+		if _, err := dal.MinioClient.FPutObject(ctx, bucketName, objectName, f.path,
+			minio.PutObjectOptions{ContentType: "image/jpeg"}); err != nil {
+			return nil, fmt.Errorf("failed to upload cover candidate %s: %w", objectName, err)
+		}
+		candidates = append(candidates, model.VideoCoverCandidate{
+			VideoID:    videoID,
+			URL:        objectName,
+			Offset:     f.offset,
+			Brightness: f.brightness,
+		})
+	}
+	return candidates, nil
+}
+
+// imageBrightness 读取 JPEG 文件并返回平均亮度
+func imageBrightness(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	if err != nil {
+		return 0, err
+	}
+	return media.MeanLuma(img), nil
+}
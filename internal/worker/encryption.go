@@ -0,0 +1,96 @@
+// internal/worker/encryption.go
+package worker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cjh/video-platform-go/internal/service"
+)
+
+// 加密档位由 ffmpeg hls muxer 输出 TS 分片，音视频复用在 media_0.m3u8 中
+const encryptedMediaPlaylist = "media_0.m3u8"
+
+// hlsEncryption 是加密档位共用的密钥文件
+type hlsEncryption struct {
+	KeyPath     string // 本地密钥文件，也是转码时写入播放列表的 URI，便于 ffprobe 读取加密分片
+	KeyInfoPath string // -hls_key_info_file
+	KeyURL      string // 上传前替换进播放列表的密钥地址
+}
+
+// prepareEncryption 取出 (或生成) 视频的内容密钥，写入 tempDir 下的密钥文件和 ffmpeg 的 key info 文件。
+// 两者不在档位的输出目录中，不会被上传
+func prepareEncryption(videoID uint64, tempDir string) (*hlsEncryption, error) {
+	key, err := service.GetOrCreateContentKey(videoID)
+	if err != nil {
+		return nil, err
+	}
+	enc := &hlsEncryption{
+		KeyPath:     filepath.Join(tempDir, "content.key"),
+		KeyInfoPath: filepath.Join(tempDir, "content.keyinfo"),
+		KeyURL:      service.ContentKeyURL(videoID),
+	}
+	if err := os.WriteFile(enc.KeyPath, key, 0600); err != nil {
+		return nil, err
+	}
+	// 第一行为播放列表中的密钥 URI，第二行为密钥文件；不指定 IV，播放器使用分片序号
+	keyInfo := enc.KeyPath + "\n" + enc.KeyPath + "\n"
+	if err := os.WriteFile(enc.KeyInfoPath, []byte(keyInfo), 0600); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// rewriteKeyURI 把媒体播放列表中的本地密钥路径替换为密钥接口的地址
+func (e *hlsEncryption) rewriteKeyURI(outputDir string) error {
+	playlistPath := filepath.Join(outputDir, encryptedMediaPlaylist)
+	data, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return err
+	}
+	local := []byte(`URI="` + e.KeyPath + `"`)
+	if !bytes.Contains(data, local) {
+		return fmt.Errorf("no EXT-X-KEY found in %s", playlistPath)
+	}
+	data = bytes.ReplaceAll(data, local, []byte(`URI="`+e.KeyURL+`"`))
+	return os.WriteFile(playlistPath, data, 0644)
+}
+
+// packagingArgs 生成档位的封装参数。不加密时 CMAF 打包为 DASH + HLS (共享 fMP4 分片)；
+// 加密时只输出 HLS，分片为 AES-128 加密的 TS
+func packagingArgs(segmentSeconds int, outputDir string, enc *hlsEncryption) []string {
+	if enc == nil {
+		return []string{
+			"-f", "dash",
+			"-seg_duration", strconv.Itoa(segmentSeconds),
+			"-use_template", "1", "-use_timeline", "1",
+			"-init_seg_name", "init-$RepresentationID$.m4s",
+			"-media_seg_name", "chunk-$RepresentationID$-$Number%05d$.m4s",
+			"-hls_playlist", "1",
+			filepath.Join(outputDir, cmafDashManifest),
+		}
+	}
+	return []string{
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segmentSeconds),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_type", "mpegts",
+		"-hls_flags", "independent_segments",
+		"-hls_key_info_file", enc.KeyInfoPath,
+		"-hls_segment_filename", filepath.Join(outputDir, "chunk-%05d.ts"),
+		"-master_pl_name", cmafHLSPlaylist,
+		filepath.Join(outputDir, encryptedMediaPlaylist),
+	}
+}
+
+// profileDir 返回档位输出的目录名: CMAF 为 cmaf_<name>，加密的 HLS 为 hls_<name>。
+// 两者分开存放，切换加密配置后重试的任务不会误用另一种格式的检查点
+func profileDir(profileName string, encrypted bool) string {
+	if encrypted {
+		return "hls_" + profileName
+	}
+	return "cmaf_" + profileName
+}
@@ -0,0 +1,41 @@
+// internal/worker/heartbeat.go
+package worker
+
+import (
+	"log"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+)
+
+// startTranscodeHeartbeat 在转码期间定期刷新 videos.updated_at，reaper 只重新提交心跳过期的视频，
+// 不会把仍在执行的长时间转码再次入队。开始时同时记录本次任务之前已经失败的次数 (attempts)，
+// Worker 中途退出后 reaper 据此继续计数。返回的函数用于停止心跳
+func startTranscodeHeartbeat(videoID uint64, attempts int) (stop func()) {
+	beat := func(updates map[string]interface{}) {
+		updates["updated_at"] = time.Now()
+		if err := dal.DB.Model(&model.Video{}).
+			Where("id = ? AND status = ?", videoID, "transcoding").
+			Updates(updates).Error; err != nil {
+			log.Printf("Failed to update transcode heartbeat of video %d: %v", videoID, err)
+		}
+	}
+	beat(map[string]interface{}{"transcode_attempts": attempts})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(service.TranscodeHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				beat(map[string]interface{}{})
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,285 @@
+// internal/worker/import.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/minio/minio-go/v7"
+	"github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
+)
+
+// importProgressInterval 是导入过程中写回 received_bytes 的最小间隔
+const importProgressInterval = 2 * time.Second
+
+var errImportTooLarge = errors.New("remote file exceeds the size limit")
+
+// importProgressReader 统计已读取的字节数，每隔 interval 通过 onProgress 报告一次，并在超过大小上限时中止读取
+type importProgressReader struct {
+	r          io.Reader
+	limit      int64 // 0 表示不限制
+	interval   time.Duration
+	onProgress func(read int64)
+	read       int64
+	lastSave   time.Time
+}
+
+func (p *importProgressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.read += int64(n)
+	if p.limit > 0 && p.read > p.limit {
+		return n, errImportTooLarge
+	}
+	if time.Since(p.lastSave) >= p.interval {
+		p.lastSave = time.Now()
+		p.onProgress(p.read)
+	}
+	return n, err
+}
+
+// newImportHTTPClient 返回导入使用的 HTTP 客户端，每次重定向都重新检查源站白名单
+func newImportHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			_, err := service.ValidateImportURL(req.URL.String())
+			return err
+		},
+	}
+}
+
+// importPartSize 返回未知长度时流式写入 MinIO 的分片大小
+func importPartSize() uint64 {
+	partSize := uint64(config.AppConfig.Upload.PartSizeMB) << 20
+	if partSize < 5<<20 {
+		partSize = 16 << 20
+	}
+	return partSize
+}
+
+// importSource 是通过了下载前检查的源站响应
+type importSource struct {
+	Body        io.ReadCloser
+	ContentType string
+	Length      int64 // Content-Length，未知时为 -1
+}
+
+// openImportSource 请求源站并做下载前的检查: 白名单 (每次重定向都会重新检查)、状态码、Content-Type 和 Content-Length。
+// 源站没有给出具体类型时按 fallbackExt 推断 Content-Type；limit 为大小上限，0 表示不限制。
+// 返回的错误都是永久性的，重试不会成功
+func openImportSource(ctx context.Context, rawURL, fallbackExt string, limit int64, timeout time.Duration) (*importSource, error) {
+	// 白名单可能在任务排队期间被修改，下载前重新检查
+	sourceURL, err := service.ValidateImportURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newImportHTTPClient(timeout).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch source: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("source returned HTTP %d", resp.StatusCode)
+	}
+
+	// Content-Type 检查，源站没有给出具体类型时根据扩展名推断
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType == "" || contentType == "application/octet-stream" || contentType == "binary/octet-stream" {
+		contentType = mime.TypeByExtension(fallbackExt)
+	}
+	if !service.ImportContentTypeAllowed(contentType) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("content type %q is not allowed", contentType)
+	}
+
+	// 大小检查: 先看 Content-Length，读取过程中再由 importProgressReader 兜底
+	if limit > 0 && resp.ContentLength > limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d bytes", errImportTooLarge, resp.ContentLength, limit)
+	}
+	return &importSource{Body: resp.Body, ContentType: contentType, Length: resp.ContentLength}, nil
+}
+
+// HandleImport 从源站下载文件并流式写入 raw/<id>/，然后走与普通上传相同的完成流程 (校验、配额、提交转码)。
+// 源站、校验和配额导致的失败会把导入标记为 failed，返回的错误包装 errPermanent；其余错误 (数据库、MinIO 暂时不可用等)
+// 不改变导入状态，任务重新投递后继续。ctx 被取消 (Worker 关闭超时) 时中止下载，同样等待重新投递。
+func HandleImport(parent context.Context, videoID uint64) error {
+	var imp model.VideoImport
+	if err := dal.DB.Where("video_id = ?", videoID).First(&imp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: import for video %d not found", errPermanent, videoID)
+		}
+		return fmt.Errorf("failed to load import of video %d: %w", videoID, err)
+	}
+	if imp.Status == "completed" || imp.Status == "failed" {
+		// 重复投递的消息
+		return nil
+	}
+	var video model.Video
+	if err := dal.DB.First(&video, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: video %d not found", errPermanent, videoID)
+		}
+		return fmt.Errorf("failed to load video %d: %w", videoID, err)
+	}
+
+	fail := func(err error) error {
+		if parent.Err() != nil {
+			return err
+		}
+		service.FailImport(videoID, err.Error())
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	switch video.Status {
+	case "uploading":
+	case "failed":
+		// 例如 reaper 已经把长时间没有进展的上传标记为失败
+		return fail(errors.New("video has been marked as failed"))
+	default:
+		// 上一次执行已经完成上传、提交了转码，只是没来得及把导入标记为完成
+		return completeImport(&imp, int64(imp.ReceivedBytes))
+	}
+
+	bucketName := config.AppConfig.MinIO.BucketName
+	var size int64
+	stored, err := dal.MinioClient.StatObject(parent, bucketName, video.RawObjectKey(), minio.StatObjectOptions{})
+	switch {
+	case err == nil:
+		// PutObject 成功后对象才会出现，说明上一次执行已经完整下载，只需重新完成上传
+		size = stored.Size
+		log.Printf("Video %d was already imported (%d bytes), completing upload", videoID, size)
+	case minio.ToErrorResponse(err).Code != "NoSuchKey":
+		return fmt.Errorf("failed to check raw object of video %d: %w", videoID, err)
+	default:
+		if size, err = downloadImport(parent, &imp, &video, fail); err != nil {
+			return err
+		}
+	}
+
+	// 与客户端调用 /upload/complete 的逻辑相同
+	if err := service.CompleteUploadService(videoID, ""); err != nil {
+		var verr *service.UploadVerificationError
+		var qerr *service.QuotaExceededError
+		if (errors.As(err, &verr) && !verr.Retryable) || errors.As(err, &qerr) {
+			return fail(err)
+		}
+		return err
+	}
+	return completeImport(&imp, size)
+}
+
+// downloadImport 把源站文件流式写入 MinIO (不落本地磁盘)，返回写入的字节数。fail 用于把导入标记为失败
+func downloadImport(parent context.Context, imp *model.VideoImport, video *model.Video, fail func(error) error) (int64, error) {
+	timeout := time.Duration(config.AppConfig.Import.TimeoutMinutes) * time.Minute
+	if timeout <= 0 {
+		timeout = time.Hour
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	limit := config.AppConfig.Upload.MaxFileSizeMB << 20
+	source, err := openImportSource(ctx, imp.SourceURL, path.Ext(video.RawObjectKey()), limit, timeout)
+	if err != nil {
+		return 0, fail(err)
+	}
+	defer source.Body.Close()
+
+	totalBytes := uint64(0)
+	if source.Length > 0 {
+		totalBytes = uint64(source.Length)
+	}
+	if err := dal.DB.Model(imp).Updates(map[string]interface{}{
+		"status":         "downloading",
+		"total_bytes":    totalBytes,
+		"received_bytes": 0,
+	}).Error; err != nil {
+		return 0, err
+	}
+	log.Printf("Importing video %d from %s (%d bytes)", video.ID, imp.SourceURL, source.Length)
+
+	reader := &importProgressReader{
+		r:        source.Body,
+		limit:    limit,
+		interval: importProgressInterval,
+		onProgress: func(read int64) {
+			dal.DB.Model(&model.VideoImport{}).Where("video_id = ?", video.ID).Update("received_bytes", read)
+		},
+		lastSave: time.Now(),
+	}
+	info, err := dal.MinioClient.PutObject(ctx, config.AppConfig.MinIO.BucketName, video.RawObjectKey(), reader, source.Length,
+		minio.PutObjectOptions{ContentType: source.ContentType, PartSize: importPartSize()})
+	if err != nil {
+		switch {
+		case parent.Err() != nil:
+			return 0, err
+		case errors.Is(err, errImportTooLarge), ctx.Err() != nil:
+			// 超过大小上限或下载超时，重试也不会成功
+			return 0, fail(fmt.Errorf("failed to store imported file: %w", err))
+		}
+		// 源站连接中断或 MinIO 暂时不可用，重新投递后从头下载
+		return 0, fmt.Errorf("failed to store imported file: %w", err)
+	}
+	if err := dal.DB.Model(imp).Update("received_bytes", info.Size).Error; err != nil {
+		return 0, err
+	}
+	log.Printf("Imported video %d: %d bytes stored as %s", video.ID, info.Size, video.RawObjectKey())
+	return info.Size, nil
+}
+
+// completeImport 在上传完成 (已提交转码) 后把导入标记为完成
+func completeImport(imp *model.VideoImport, size int64) error {
+	return dal.DB.Model(imp).Updates(map[string]interface{}{
+		"status":         "completed",
+		"received_bytes": size,
+	}).Error
+}
+
+// HandleImportDelivery 处理导入队列中的一个任务。永久性错误丢弃消息，暂时性错误和因 Worker 关闭而中止的任务退回队列
+func HandleImportDelivery(ctx context.Context, d amqp091.Delivery) {
+	var task service.ImportTaskPayload
+	if err := json.Unmarshal(d.Body, &task); err != nil {
+		log.Printf("Error unmarshalling import task: %s", err)
+		d.Nack(false, false) // 消息格式错误，直接丢弃
+		return
+	}
+
+	if err := HandleImport(ctx, task.VideoID); err != nil {
+		if ctx.Err() != nil {
+			log.Printf("Import of video %d interrupted by shutdown, requeueing", task.VideoID)
+			d.Nack(false, true)
+			return
+		}
+		if errors.Is(err, errPermanent) {
+			log.Printf("Failed to import video %d: %v", task.VideoID, err)
+			d.Nack(false, false)
+			return
+		}
+		log.Printf("Import of video %d failed, requeueing in %s: %v", task.VideoID, transientRetryDelay, err)
+		requeueAfterDelay(ctx, d)
+		return
+	}
+	log.Printf("Successfully imported video %d", task.VideoID)
+	d.Ack(false)
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/service"
+)
+
+// setupImportSource 启动本地源站，并把它加入导入白名单
+func setupImportSource(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	saved := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = saved })
+	u, _ := url.Parse(srv.URL)
+	config.AppConfig.Import.AllowedHosts = []string{u.Host}
+	config.AppConfig.Upload.AllowedContentTypes = []string{"video/", "audio/"}
+	return srv
+}
+
+func serveVideo(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		io.WriteString(w, body)
+	}
+}
+
+func TestOpenImportSourceAllowlist(t *testing.T) {
+	srv := setupImportSource(t, serveVideo("data"))
+
+	source, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 0, time.Minute)
+	if err != nil {
+		t.Fatalf("allowed host rejected: %v", err)
+	}
+	source.Body.Close()
+
+	config.AppConfig.Import.AllowedHosts = []string{"archive.internal"}
+	_, err = openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 0, time.Minute)
+	var uerr *service.ImportURLError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected ImportURLError for host outside the allowlist, got %v", err)
+	}
+}
+
+func TestOpenImportSourceRedirectOutsideAllowlist(t *testing.T) {
+	other := httptest.NewServer(serveVideo("data"))
+	defer other.Close()
+	srv := setupImportSource(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+"/a.mp4", http.StatusFound)
+	})
+
+	_, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 0, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "allowlist") {
+		t.Fatalf("expected redirect to a host outside the allowlist to fail, got %v", err)
+	}
+}
+
+func TestOpenImportSourceContentType(t *testing.T) {
+	srv := setupImportSource(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.URL.Query().Get("type"))
+		io.WriteString(w, "data")
+	})
+
+	cases := []struct {
+		contentType string
+		allowed     bool
+	}{
+		{"video/mp4", true},
+		{"audio/mpeg; charset=binary", true},
+		{"text/html; charset=utf-8", false},
+		{"application/json", false},
+	}
+	for _, tc := range cases {
+		source, err := openImportSource(context.Background(), srv.URL+"/a.mp4?type="+url.QueryEscape(tc.contentType), ".mp4", 0, time.Minute)
+		if tc.allowed != (err == nil) {
+			t.Errorf("content type %q: allowed=%v, err=%v", tc.contentType, tc.allowed, err)
+		}
+		if err == nil {
+			source.Body.Close()
+		}
+	}
+}
+
+func TestOpenImportSourceContentLengthLimit(t *testing.T) {
+	srv := setupImportSource(t, serveVideo(strings.Repeat("x", 100)))
+
+	_, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 50, time.Minute)
+	if !errors.Is(err, errImportTooLarge) {
+		t.Fatalf("expected errImportTooLarge, got %v", err)
+	}
+
+	source, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 100, time.Minute)
+	if err != nil {
+		t.Fatalf("file at the limit rejected: %v", err)
+	}
+	source.Body.Close()
+}
+
+func TestImportProgressReaderLimitWithoutContentLength(t *testing.T) {
+	// 分块传输，源站不给出 Content-Length，只能在读取过程中发现超限
+	srv := setupImportSource(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		for i := 0; i < 10; i++ {
+			io.WriteString(w, strings.Repeat("x", 10))
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	source, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 50, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error before reading: %v", err)
+	}
+	defer source.Body.Close()
+	if source.Length != -1 {
+		t.Fatalf("expected unknown length, got %d", source.Length)
+	}
+
+	reader := &importProgressReader{r: source.Body, limit: 50, interval: time.Hour, onProgress: func(int64) {}, lastSave: time.Now()}
+	if _, err := io.Copy(io.Discard, reader); !errors.Is(err, errImportTooLarge) {
+		t.Fatalf("expected errImportTooLarge while reading, got %v", err)
+	}
+}
+
+func TestImportProgressReaderReportsProgress(t *testing.T) {
+	const size = 64 << 10
+	srv := setupImportSource(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		io.WriteString(w, strings.Repeat("x", size))
+	})
+
+	source, err := openImportSource(context.Background(), srv.URL+"/a.mp4", ".mp4", 0, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Body.Close()
+
+	var reports []int64
+	reader := &importProgressReader{
+		r:          source.Body,
+		interval:   0, // 每次读取都报告
+		onProgress: func(read int64) { reports = append(reports, read) },
+	}
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil || n != size {
+		t.Fatalf("read %d bytes, err %v", n, err)
+	}
+	if len(reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i] < reports[i-1] {
+			t.Fatalf("progress went backwards: %v", reports)
+		}
+	}
+	if last := reports[len(reports)-1]; last != size {
+		t.Fatalf("last progress %d, want %d", last, size)
+	}
+}
@@ -0,0 +1,63 @@
+// internal/worker/loudness.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+)
+
+// maxNormalizedSampleRate loudnorm 内部把音频上采样到 192 kHz，标准化后需要重新采样，不超过 48 kHz
+const maxNormalizedSampleRate = 48000
+
+// loudnessTarget 返回配置中的响度标准化目标
+func loudnessTarget() media.LoudnessTarget {
+	cfg := config.AppConfig.FFMpeg.Loudness
+	return media.LoudnessTarget{
+		IntegratedLUFS: cfg.IntegratedLUFS,
+		TruePeakDBTP:   cfg.TruePeakDBTP,
+		LoudnessRange:  cfg.LoudnessRange,
+	}
+}
+
+// measureLoudness 是 loudnorm 的第一遍: 解码原始文件的第一个音频流，测量综合响度、真峰值和响度范围
+func measureLoudness(ctx context.Context, input string, target media.LoudnessTarget) (*media.LoudnessMeasurement, error) {
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-nostats", "-i", input,
+		"-map", "0:a:0", "-af", media.LoudnormMeasureFilter(target), "-f", "null", "-")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed to measure loudness: %w: %s", err, string(output))
+	}
+	return media.ParseLoudnormStats(output)
+}
+
+// normalizationFilter 返回第二遍的音频滤镜: 按测量值标准化，再重新采样到原始采样率 (最高 48 kHz)
+func normalizationFilter(target media.LoudnessTarget, m *media.LoudnessMeasurement, metadata *model.VideoMetadata) string {
+	sampleRate := maxNormalizedSampleRate
+	for _, s := range metadata.Streams {
+		if s.Type == "audio" {
+			if s.SampleRate > 0 {
+				sampleRate = min(s.SampleRate, maxNormalizedSampleRate)
+			}
+			break
+		}
+	}
+	return fmt.Sprintf("%s,aresample=%d", media.LoudnormFilter(target, m), sampleRate)
+}
+
+// newVideoLoudness 整理要保存的响度记录。标准化后播放源的综合响度即为目标值，ReplayGain 增益据此计算
+func newVideoLoudness(videoID uint64, target media.LoudnessTarget, m *media.LoudnessMeasurement) *model.VideoLoudness {
+	return &model.VideoLoudness{
+		VideoID:        videoID,
+		IntegratedLUFS: m.IntegratedLUFS,
+		TruePeakDBTP:   m.TruePeakDBTP,
+		LoudnessRange:  m.LoudnessRange,
+		Threshold:      m.Threshold,
+		TargetLUFS:     target.IntegratedLUFS,
+		ReplayGainDB:   model.ReplayGainReferenceLUFS - target.IntegratedLUFS,
+	}
+}
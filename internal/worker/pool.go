@@ -0,0 +1,31 @@
+// internal/worker/pool.go
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ConsumeConcurrently 启动 concurrency 个 goroutine 处理 msgs，直到 msgs 关闭 (消费者被取消)。
+//   - ctx 结束 (收到退出信号) 后不再开始新任务，之后收到的预取消息直接退回队列；
+//   - jobCtx 传给正在执行的任务，关闭超时后取消，由 handle 负责把中止的任务退回队列。
+//
+// 每个 goroutine 退出时调用 wg.Done，调用方据此等待正在执行的任务结束。
+func ConsumeConcurrently(ctx, jobCtx context.Context, wg *sync.WaitGroup, msgs <-chan amqp091.Delivery, concurrency int,
+	handle func(context.Context, amqp091.Delivery)) {
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range msgs {
+				if ctx.Err() != nil {
+					d.Nack(false, true)
+					continue
+				}
+				handle(jobCtx, d)
+			}
+		}()
+	}
+}
@@ -0,0 +1,116 @@
+// internal/worker/progress.go
+package worker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math"
+	"os/exec"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/cjh/video-platform-go/internal/service"
+)
+
+// progressInterval 是向 Redis 上报进度的最小间隔，ffmpeg 默认每 0.5 秒输出一次
+const progressInterval = time.Second
+
+// progressReporter 把各个档位的 ffmpeg 进度汇总为整个视频的进度。
+// 每个档位都要处理完整的时长，所以总体进度按档位平均计算。
+type progressReporter struct {
+	videoID  uint64
+	duration float64 // 原始视频时长 (秒)，未知时为 0
+	profiles []service.ProfileProgress
+	lastSent time.Time
+}
+
+func newProgressReporter(videoID uint64, duration float64, profileNames []string) *progressReporter {
+	r := &progressReporter{videoID: videoID, duration: duration}
+	for _, name := range profileNames {
+		r.profiles = append(r.profiles, service.ProfileProgress{Name: name})
+	}
+	return r
+}
+
+// update 处理第 index 个档位的一次 ffmpeg 进度输出
+func (r *progressReporter) update(index int, p media.Progress) {
+	fraction := 0.0
+	if p.Done {
+		fraction = 1
+	} else if r.duration > 0 {
+		fraction = math.Min(p.OutTime.Seconds()/r.duration, 1)
+	}
+	r.profiles[index].Percent = math.Round(fraction*1000) / 10
+
+	if !p.Done && time.Since(r.lastSent) < progressInterval {
+		return
+	}
+
+	progress := &service.TranscodeProgress{
+		VideoID:    r.videoID,
+		Status:     "transcoding",
+		Profile:    r.profiles[index].Name,
+		Profiles:   r.profiles,
+		Speed:      p.Speed,
+		ETASeconds: -1,
+	}
+	if count := len(r.profiles); count > 0 {
+		progress.Percent = math.Round((float64(index)+fraction)/float64(count)*1000) / 10
+	}
+	// 剩余时间: 当前档位剩余的时长加上后续档位的完整时长，按当前速度估算
+	if p.Speed > 0 && r.duration > 0 {
+		remaining := (1-fraction)*r.duration + float64(len(r.profiles)-index-1)*r.duration
+		progress.ETASeconds = int(math.Ceil(remaining / p.Speed))
+	}
+	r.publish(progress)
+}
+
+// finish 在转码结果写入数据库后上报最终状态，订阅者收到后结束推送
+func (r *progressReporter) finish() {
+	for i := range r.profiles {
+		r.profiles[i].Percent = 100
+	}
+	r.publish(&service.TranscodeProgress{
+		VideoID:  r.videoID,
+		Status:   "online",
+		Profiles: r.profiles,
+		Percent:  100,
+	})
+}
+
+func (r *progressReporter) publish(progress *service.TranscodeProgress) {
+	r.lastSent = time.Now()
+	// 进度只用于展示，上报失败不影响转码
+	if err := service.PublishTranscodeProgress(context.Background(), progress); err != nil {
+		log.Printf("Failed to publish progress of video %d: %v", r.videoID, err)
+	}
+}
+
+// runFFmpegWithProgress 执行 ffmpeg 并通过 -progress pipe:1 逐块回调进度，失败时把 ffmpeg 的 stderr 写入日志。
+// ctx 取消时 ffmpeg 会被杀死
+func runFFmpegWithProgress(ctx context.Context, args []string, onProgress func(media.Progress)) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg", append([]string{"-progress", "pipe:1", "-nostats"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Executing %s", cmd.String())
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := media.ReadProgress(stdout, onProgress); err != nil {
+		log.Printf("Failed to read ffmpeg progress: %v", err)
+		// 继续读完输出，避免 ffmpeg 写满管道后阻塞
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		log.Printf("ffmpeg output: %s", stderr.String())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// internal/worker/reaper.go
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/service"
+)
+
+// RunReaper 按 reaper.interval_minutes 定期清理过期上传、孤立对象和卡住的转码，直到 ctx 结束。
+// 每次执行的动作数和启动以来的累计数都会写入日志。
+func RunReaper(ctx context.Context) {
+	interval := time.Duration(config.AppConfig.Reaper.IntervalMinutes) * time.Minute
+	if interval <= 0 {
+		log.Println("Reaper: disabled")
+		return
+	}
+	log.Printf("Reaper: running every %s", interval)
+
+	var totals service.ReaperStats
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		started := time.Now()
+		stats := service.RunReaperPass(ctx)
+		totals.Add(stats)
+		log.Printf("Reaper: pass finished in %s: %s", time.Since(started).Round(time.Millisecond), stats)
+		log.Printf("Reaper: totals since start: %s", totals)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// internal/worker/retry.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// errPermanent 标记重试也不会成功的错误 (视频不存在、原始文件无法解析等)，直接转入死信队列
+var errPermanent = errors.New("permanent failure")
+
+// HandleTranscodeDelivery 处理一条转码任务消息。失败时按配置延迟重试，重试耗尽或遇到永久错误时转入死信队列，
+// 并把视频标记为 dead_lettered (没有配置死信队列时为 failed)；重试通过重新发布实现。因 Worker 关闭而中止的任务退回原队列，不计入重试次数。
+func HandleTranscodeDelivery(ctx context.Context, d amqp091.Delivery) {
+	var task service.TranscodeTaskPayload
+	if err := json.Unmarshal(d.Body, &task); err != nil {
+		log.Printf("Error unmarshalling message: %s", err)
+		d.Nack(false, false) // 消息格式错误，直接丢弃
+		return
+	}
+
+	stopHeartbeat := startTranscodeHeartbeat(task.VideoID, service.TaskAttempts(d.Headers))
+	err := HandleTranscode(ctx, task.VideoID)
+	stopHeartbeat()
+	if err == nil {
+		log.Printf("Successfully transcoded video %d", task.VideoID)
+		d.Ack(false)
+		return
+	}
+	if ctx.Err() != nil {
+		log.Printf("Transcode of video %d interrupted by shutdown, requeueing", task.VideoID)
+		d.Nack(false, true)
+		return
+	}
+
+	cfg := config.AppConfig.RabbitMQ
+	attempts := service.TaskAttempts(d.Headers) + 1
+	log.Printf("Transcode attempt %d/%d for video %d failed: %v", attempts, cfg.TranscodeMaxAttempts, task.VideoID, err)
+
+	headers := amqp091.Table{
+		service.HeaderRetryCount: int32(attempts),
+		service.HeaderLastError:  truncateError(err),
+	}
+	// ctx 只用于转码本身，发布重试 / 死信消息使用独立的 context
+	ctx = context.Background()
+
+	if !errors.Is(err, errPermanent) && attempts < cfg.TranscodeMaxAttempts && len(cfg.TranscodeRetryDelaysSeconds) > 0 {
+		delays := cfg.TranscodeRetryDelaysSeconds
+		delay := delays[min(attempts, len(delays))-1]
+		if perr := dal.PublishTranscodeTaskTo(ctx, dal.TranscodeRetryQueue(delay), d.Body, headers); perr != nil {
+			// 重试消息发不出去时退回原队列，避免丢失任务
+			log.Printf("Failed to schedule retry for video %d: %v", task.VideoID, perr)
+			d.Nack(false, true)
+			return
+		}
+		log.Printf("Video %d will be retried in %ds", task.VideoID, delay)
+		d.Ack(false)
+		return
+	}
+
+	status := "failed"
+	if dlq := cfg.TranscodeDeadLetterQueue; dlq != "" {
+		headers[service.HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+		if perr := dal.PublishTranscodeTaskTo(ctx, dlq, d.Body, headers); perr != nil {
+			log.Printf("Failed to dead-letter video %d: %v", task.VideoID, perr)
+			d.Nack(false, true)
+			return
+		}
+		log.Printf("Video %d moved to dead-letter queue %s after %d attempts", task.VideoID, dlq, attempts)
+		// reaper 不会清理 dead_lettered 视频的原始文件，任务可以重新投递
+		status = "dead_lettered"
+	}
+	// 只有确定不再重试时才标记失败，重试期间视频保持 transcoding
+	dal.DB.Model(&model.Video{}).Where("id = ? AND status = ?", task.VideoID, "transcoding").Update("status", status)
+	d.Ack(false)
+}
+
+// transientRetryDelay 是导入、上传事件等任务遇到暂时性错误 (数据库、MinIO 不可用等) 后退回队列前的等待时间
+const transientRetryDelay = 10 * time.Second
+
+// requeueAfterDelay 等待 transientRetryDelay 后把消息退回原队列，避免依赖的服务不可用时反复空转。
+// Worker 关闭时立即退回
+func requeueAfterDelay(ctx context.Context, d amqp091.Delivery) {
+	select {
+	case <-time.After(transientRetryDelay):
+	case <-ctx.Done():
+	}
+	d.Nack(false, true)
+}
+
+// truncateError 截断错误信息，ffmpeg 的错误可能很长，而 AMQP header 不适合存放大量内容
+func truncateError(err error) string {
+	const maxLen = 1024
+	msg := err.Error()
+	if len(msg) > maxLen {
+		msg = msg[:maxLen] + "..."
+	}
+	return msg
+}
@@ -0,0 +1,78 @@
+// internal/worker/thumbnails.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cjh/video-platform-go/internal/config"
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/minio/minio-go/v7"
+)
+
+// thumbnailVTTName 是缩略图轨道的文件名，雪碧图为同目录下的 sprite-001.jpg、sprite-002.jpg ...
+const thumbnailVTTName = "thumbnails.vtt"
+
+// generateThumbnails 按 ffmpeg.thumbnails 配置生成进度条预览用的雪碧图和 WebVTT 文件，
+// 上传到 processed/<id>/thumbs/ 并返回 VTT 的对象路径。未启用或无法生成 (没有视频流) 时返回空字符串。
+func generateThumbnails(ctx context.Context, bucketName string, videoID uint64, input, tempDir string, metadata *model.VideoMetadata) (string, error) {
+	cfg := config.AppConfig.FFMpeg.Thumbnails
+	if cfg.IntervalSeconds <= 0 || metadata.Duration <= 0 || metadata.Width <= 0 || metadata.Height <= 0 {
+		return "", nil
+	}
+
+	prefix := fmt.Sprintf("processed/%d/thumbs", videoID)
+	vttObject := prefix + "/" + thumbnailVTTName
+	// VTT 最后上传，存在即说明上次执行已经完成
+	if objectExists(ctx, bucketName, vttObject) {
+		return vttObject, nil
+	}
+
+	// 按显示尺寸 (ffmpeg 会自动旋转) 计算缩略图高度，保持偶数
+	height := max(2, int(math.Round(float64(cfg.Width)*float64(metadata.Height)/float64(metadata.Width)/2))*2)
+	outputDir := filepath.Join(tempDir, "thumbs")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	filter := fmt.Sprintf("fps=1/%d,scale=%d:%d,tile=%dx%d", cfg.IntervalSeconds, cfg.Width, height, cfg.Columns, cfg.Rows)
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", input, "-map", "0:v:0", "-vf", filter, "-q:v", "5",
+		filepath.Join(outputDir, "sprite-%03d.jpg"))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed to generate sprites: %w: %s", err, string(output))
+	}
+
+	sheets, err := filepath.Glob(filepath.Join(outputDir, "sprite-*.jpg"))
+	if err != nil || len(sheets) == 0 {
+		return "", fmt.Errorf("no sprite sheets generated")
+	}
+	layout := media.SpriteLayout{
+		Interval:  float64(cfg.IntervalSeconds),
+		Width:     cfg.Width,
+		Height:    height,
+		Columns:   cfg.Columns,
+		Rows:      cfg.Rows,
+		SheetName: func(n int) string { return fmt.Sprintf("sprite-%03d.jpg", n) },
+	}
+	count := min(int(math.Ceil(metadata.Duration/layout.Interval)), len(sheets)*cfg.Columns*cfg.Rows)
+	vtt := media.BuildThumbnailVTT(layout, count, metadata.Duration)
+
+	for _, sheet := range sheets {
+		if _, err := dal.MinioClient.FPutObject(ctx, bucketName, prefix+"/"+filepath.Base(sheet), sheet,
+			minio.PutObjectOptions{ContentType: "image/jpeg"}); err != nil {
+			return "", fmt.Errorf("failed to upload sprite %s: %w", filepath.Base(sheet), err)
+		}
+	}
+	if _, err := dal.MinioClient.PutObject(ctx, bucketName, vttObject, strings.NewReader(vtt), int64(len(vtt)),
+		minio.PutObjectOptions{ContentType: "text/vtt"}); err != nil {
+		return "", fmt.Errorf("failed to upload thumbnail track: %w", err)
+	}
+	return vttObject, nil
+}
@@ -3,35 +3,258 @@ package worker
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/cjh/video-platform-go/internal/config"
 	"github.com/cjh/video-platform-go/internal/dal"
 	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/media"
+	"github.com/cjh/video-platform-go/internal/service"
 	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
 )
 
-// ffprobe 用于解析视频信息的结构体
-type ffprobeFormat struct {
-	Duration string `json:"duration"`
+// CMAF 打包输出的文件名 (ffmpeg dash muxer 开启 -hls_playlist 后，HLS 主播放列表固定为 master.m3u8)
+const (
+	cmafDashManifest = "manifest.mpd"
+	cmafHLSPlaylist  = "master.m3u8"
+)
+
+// selectProfiles 返回不超过原始视频高度的转码档位 (不放大)。
+// 原始视频比最低档位还小时，仍以最低档位的参数按原始高度转码一份，保证至少有一个播放源。
+// sourceHeight 未知 (0) 时使用全部档位。
+func selectProfiles(profiles []config.Profile, sourceHeight int) []config.Profile {
+	if sourceHeight <= 0 {
+		return profiles
+	}
+	var selected []config.Profile
+	var lowest *config.Profile
+	for i := range profiles {
+		p := profiles[i]
+		if lowest == nil || p.Height < lowest.Height {
+			lowest = &profiles[i]
+		}
+		if p.Height > sourceHeight {
+			log.Printf("Skipping profile %s: %dp exceeds source height %d", p.Name, p.Height, sourceHeight)
+			continue
+		}
+		selected = append(selected, p)
+	}
+	if len(selected) == 0 && lowest != nil {
+		fallback := *lowest
+		fallback.Height = sourceHeight &^ 1 // yuv420p 要求偶数高度
+		log.Printf("Source height %d is below every profile, transcoding %s at %dp", sourceHeight, fallback.Name, fallback.Height)
+		selected = append(selected, fallback)
+	}
+	return selected
 }
-type ffprobeOutput struct {
-	Format ffprobeFormat `json:"format"`
+
+// transcodeOptions 是所有视频档位共用的转码参数
+type transcodeOptions struct {
+	SourceFrameRate float64           // 原始视频帧率，未知时为 0
+	AudioFilter     string            // 响度标准化滤镜，不做标准化时为空
+	Watermark       *watermarkOverlay // 叠加的水印，没有时为 nil
+	Encryption      *hlsEncryption    // HLS 加密，不加密时为 nil
+}
+
+// transcodeArgs 生成单个档位的 ffmpeg 参数: 按档位配置编码 (可选叠加水印)，CMAF 打包为 DASH + HLS，
+// 加密时打包为加密的 HLS。输出写入 outputDir。
+func transcodeArgs(profile config.Profile, input, outputDir string, opts transcodeOptions) []string {
+	frameRate := opts.SourceFrameRate
+	filters := fmt.Sprintf("scale=-2:%d", profile.Height)
+	if profile.MaxFrameRate > 0 && opts.SourceFrameRate > profile.MaxFrameRate {
+		frameRate = profile.MaxFrameRate
+		filters += fmt.Sprintf(",fps=%g", profile.MaxFrameRate)
+	}
+
+	var args []string
+	if opts.Watermark != nil {
+		args = []string{
+			"-i", input, "-i", opts.Watermark.Path,
+			"-filter_complex", opts.Watermark.filterComplex(filters, profile.Height),
+			"-map", "[v]", "-map", "0:a:0?",
+		}
+	} else {
+		args = []string{
+			"-i", input,
+			"-map", "0:v:0", "-map", "0:a:0?",
+			"-vf", filters,
+		}
+	}
+	args = append(args, "-c:v", profile.VideoCodec, "-preset", profile.Preset, "-pix_fmt", "yuv420p")
+	if profile.VideoCodec == "libx265" && opts.Encryption == nil {
+		// Apple 设备只播放 hvc1 标记的 HEVC (fMP4 分片)；TS 分片没有这个标记
+		args = append(args, "-tag:v", "hvc1")
+	}
+	if profile.VideoBitrate != "" {
+		args = append(args, "-b:v", profile.VideoBitrate)
+	}
+	if profile.MaxRate != "" {
+		args = append(args, "-maxrate", profile.MaxRate, "-bufsize", profile.BufSize)
+	}
+
+	// 固定 GOP，保证每个分片都以关键帧开头
+	gop := strconv.Itoa(profile.GOPSeconds)
+	args = append(args, "-force_key_frames", "expr:gte(t,n_forced*"+gop+")")
+	if frameRate > 0 {
+		args = append(args, "-g", strconv.Itoa(int(math.Round(frameRate*float64(profile.GOPSeconds)))))
+	}
+
+	if opts.AudioFilter != "" {
+		args = append(args, "-af", opts.AudioFilter)
+	}
+	args = append(args, "-c:a", profile.AudioCodec, "-b:a", profile.AudioBitrate)
+	return append(args, packagingArgs(profile.SegmentSeconds, outputDir, opts.Encryption)...)
 }
 
-// HandleTranscode 是处理转码任务的核心函数 (V2版)
-func HandleTranscode(videoID uint64) error {
+// describeCMAFVariant 从 ffmpeg dash muxer 写出的 HLS 媒体播放列表中读取该档位的码流属性。
+// 开启 -hls_playlist 后视频流为 media_0.m3u8，音频流 (如果有) 为 media_1.m3u8，返回的 URI 相对 outputDir。
+// 纯音频档位只有 media_0.m3u8，其中是音频流。加密的 HLS 档位音视频都复用在 media_0.m3u8 中。
+func describeCMAFVariant(ctx context.Context, outputDir string) (*media.HLSVariant, error) {
+	variant := &media.HLSVariant{URI: "media_0.m3u8"}
+	firstPlaylist := filepath.Join(outputDir, variant.URI)
+
+	probe, err := media.Probe(ctx, firstPlaylist)
+	if err != nil {
+		return nil, err
+	}
+	var codecs []string
+	videoStream, audioStream := probe.FirstStream("video"), probe.FirstStream("audio")
+	if videoStream == nil && audioStream == nil {
+		return nil, fmt.Errorf("no video or audio stream in %s", firstPlaylist)
+	}
+	if videoStream != nil {
+		variant.Width = videoStream.Width
+		variant.Height = videoStream.Height
+		variant.FrameRate = videoStream.FrameRate()
+		codecs = append(codecs, media.CodecString(videoStream))
+	}
+	if audioStream != nil {
+		codecs = append(codecs, media.CodecString(audioStream))
+	}
+
+	peak, average, err := media.PlaylistBitrate(firstPlaylist)
+	if err != nil {
+		return nil, err
+	}
+	variant.Bandwidth, variant.AverageBandwidth = peak, average
+
+	audioPlaylist := filepath.Join(outputDir, "media_1.m3u8")
+	if _, err := os.Stat(audioPlaylist); err == nil {
+		variant.AudioURI = "media_1.m3u8"
+		audioProbe, err := media.Probe(ctx, audioPlaylist)
+		if err != nil {
+			return nil, err
+		}
+		if audioStream := audioProbe.FirstStream("audio"); audioStream != nil {
+			codecs = append(codecs, media.CodecString(audioStream))
+		}
+		peak, average, err := media.PlaylistBitrate(audioPlaylist)
+		if err != nil {
+			return nil, err
+		}
+		variant.Bandwidth += peak
+		variant.AverageBandwidth += average
+	}
+
+	// 任何一个流的编码无法识别时省略 CODECS，播放器会自己探测
+	for _, c := range codecs {
+		if c == "" {
+			codecs = nil
+			break
+		}
+	}
+	variant.Codecs = strings.Join(codecs, ",")
+	return variant, nil
+}
+
+// uploadMasterPlaylist 生成并上传 processed/<id>/master.m3u8，返回对应的 auto 播放源
+func uploadMasterPlaylist(ctx context.Context, bucketName string, videoID uint64, variants []media.HLSVariant) (*model.VideoSource, error) {
+	playlist := media.BuildMasterPlaylist(variants)
+	objectName := fmt.Sprintf("processed/%d/master.m3u8", videoID)
+	if _, err := dal.MinioClient.PutObject(ctx, bucketName, objectName,
+		strings.NewReader(playlist), int64(len(playlist)),
+		minio.PutObjectOptions{ContentType: "application/vnd.apple.mpegurl"},
+	); err != nil {
+		return nil, fmt.Errorf("failed to upload master playlist: %w", err)
+	}
+
+	// auto 播放源的属性取最高档位，便于客户端展示
+	source := &model.VideoSource{
+		VideoID:  videoID,
+		Quality:  model.SourceQualityAuto,
+		Format:   model.SourceFormatHLS,
+		URL:      objectName,
+		FileSize: uint64(len(playlist)),
+	}
+	for _, v := range variants {
+		if v.Bandwidth > source.Bandwidth {
+			source.Bandwidth = v.Bandwidth
+			source.Width, source.Height = v.Width, v.Height
+			source.Codecs, source.FrameRate = v.Codecs, v.FrameRate
+		}
+	}
+	return source, nil
+}
+
+// refreshSubtitleMaster 转码前上传的字幕在主播放列表生成后才能加入字幕组，字幕播放列表的时长也要
+// 按实际的视频时长重新生成；失败只记录日志，下次修改字幕时会重新生成
+func refreshSubtitleMaster(ctx context.Context, videoID uint64) {
+	if err := service.RebuildSubtitlePlaylists(ctx, videoID); err != nil {
+		log.Printf("Failed to rebuild subtitle playlists of video %d: %v", videoID, err)
+	}
+	if err := service.RebuildSubtitleMasterPlaylist(ctx, videoID); err != nil {
+		log.Printf("Failed to rebuild subtitle master playlist of video %d: %v", videoID, err)
+	}
+}
+
+// downloadAndHash 把原始文件下载到 localPath，并返回其 SHA-256 (十六进制)
+func downloadAndHash(ctx context.Context, bucketName, objectName, localPath string) (string, error) {
+	obj, err := dal.MinioClient.GetObject(ctx, bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	f, err := os.Create(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hasher), obj); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), f.Close()
+}
+
+// HandleTranscode 是处理转码任务的核心函数 (V2版)。ctx 被取消 (Worker 关闭超时) 时中止下载和 ffmpeg 并返回错误
+func HandleTranscode(ctx context.Context, videoID uint64) error {
 	// --- 0. 准备工作 ---
 	var video model.Video
-	// 从数据库获取视频的完整信息，包括了我们新加的 OriginalFileName
+	// 从数据库获取视频的完整信息，包括服务端生成的原始文件对象路径
 	if err := dal.DB.First(&video, videoID).Error; err != nil {
-		return fmt.Errorf("video %d not found: %w", videoID, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: video %d not found", errPermanent, videoID)
+		}
+		return fmt.Errorf("failed to load video %d: %w", videoID, err)
+	}
+	// 重复投递、重试期间视频被删除后重新上传等情况: 只处理仍在等待转码的视频
+	if video.Status != "transcoding" {
+		log.Printf("Video %d is %s, skipping stale transcode task", videoID, video.Status)
+		return nil
 	}
 
 	tempDir, err := os.MkdirTemp("", fmt.Sprintf("video-%d-*", videoID))
@@ -42,76 +265,221 @@ func HandleTranscode(videoID uint64) error {
 
 	bucketName := config.AppConfig.MinIO.BucketName
 
-	// 【核心修改】使用 video.OriginalFileName 而不是 video.Title 来构建下载路径
-	rawObjectName := filepath.Join("raw", fmt.Sprintf("%d", video.ID), video.OriginalFileName)
-	// 本地保存的文件名也使用 OriginalFileName，保持一致性
-	localRawPath := filepath.Join(tempDir, video.OriginalFileName)
+	// 对象路径和本地文件名都不使用客户端提供的文件名，只保留 (已清理的) 扩展名供 ffmpeg 识别格式
+	rawObjectName := video.RawObjectKey()
+	if rawObjectName == "" {
+		// 旧版本创建的视频，需要先运行 cmd/migrate-keys；迁移后可以从死信队列重新投递
+		return fmt.Errorf("%w: video %d has no object key, run cmd/migrate-keys first", errPermanent, videoID)
+	}
+	localRawPath := filepath.Join(tempDir, "source"+path.Ext(rawObjectName))
 
-	// 下载原始视频文件
-	if err := dal.MinioClient.FGetObject(context.Background(), bucketName, rawObjectName, localRawPath, minio.GetObjectOptions{}); err != nil {
-		// 下载失败，更新数据库状态并返回错误
-		dal.DB.Model(&video).Update("status", "failed")
+	// 下载原始视频文件，同时计算 SHA-256 用于去重
+	contentHash, err := downloadAndHash(ctx, bucketName, rawObjectName, localRawPath)
+	if err != nil {
+		// 下载失败 (可能是 MinIO 暂时不可用)，交给重试逻辑处理
 		// 在日志中明确指出是哪个对象键下载失败，方便排查
 		return fmt.Errorf("failed to download from minio (key: %s): %w", rawObjectName, err)
 	}
-	log.Printf("Downloaded %s to %s", rawObjectName, localRawPath)
+	log.Printf("Downloaded %s to %s (sha256 %s)", rawObjectName, localRawPath, contentHash)
+
+	// --- 0.1 读取原始文件的元数据并保存到 video_metadata ---
+	probe, err := media.Probe(ctx, localRawPath)
+	if err != nil {
+		// 本地文件无法解析，重试也不会成功
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	metadata := service.NewVideoMetadata(videoID, probe)
+	if err := service.SaveVideoMetadata(metadata); err != nil {
+		// 元数据只用于展示和选择转码档位，保存失败不影响转码
+		log.Printf("Failed to save metadata of video %d: %v", videoID, err)
+	}
+	log.Printf("Video %d source: %s %s %dx%d@%.3f rotation %d, %s",
+		videoID, metadata.Container, metadata.VideoCodec, metadata.Width, metadata.Height, metadata.FrameRate, metadata.Rotation, metadata.AudioCodec)
+	// 没有视频流 (内嵌专辑封面不算) 的上传按纯音频处理
+	mediaType := model.MediaTypeVideo
+	if probe.IsAudioOnly() {
+		mediaType = model.MediaTypeAudio
+		log.Printf("Video %d is audio-only", videoID)
+	}
+
+	// --- 0.2 确定水印: 平台配置合并上传者的个人设置，纯音频没有画面，不叠加 ---
+	var watermark *watermarkOverlay
+	watermarkKey := ""
+	if mediaType == model.MediaTypeVideo {
+		watermark, watermarkKey, err = prepareWatermark(ctx, bucketName, &video, tempDir)
+		if err != nil {
+			return fmt.Errorf("failed to prepare watermark: %w", err)
+		}
+	}
+
+	// --- 0.3 加密: 使用视频自己的内容密钥，重试时沿用同一个密钥 ---
+	var encryption *hlsEncryption
+	encrypted := config.AppConfig.Encryption.Enabled
+	if encrypted {
+		encryption, err = prepareEncryption(videoID, tempDir)
+		if err != nil {
+			return fmt.Errorf("failed to prepare content key: %w", err)
+		}
+	}
+
+	// --- 0.4 内容去重: 已经有相同文件 (且水印相同) 的转码产物时直接复用，跳过转码。
+	// 加密的视频需要用自己的密钥转码，不复用 ---
+	var asset *model.MediaAsset
+	if !encrypted {
+		asset, err = service.FindMediaAsset(contentHash, watermarkKey)
+		if err != nil {
+			return fmt.Errorf("failed to look up media asset: %w", err)
+		}
+	}
+	if asset != nil {
+		attached, err := service.AttachMediaAsset(&video, asset.ID, contentHash)
+		if err != nil {
+			return fmt.Errorf("failed to reuse media asset %d: %w", asset.ID, err)
+		}
+		if attached {
+			log.Printf("Video %d is a duplicate of media asset %d (%s), skipped transcoding", videoID, asset.ID, asset.StoragePrefix)
+			// 清理之前中断的转码留下的文件
+			if ownPrefix := fmt.Sprintf("processed/%d", videoID); asset.StoragePrefix != ownPrefix {
+				if err := service.RemoveObjectsWithPrefix(ctx, ownPrefix); err != nil {
+					log.Printf("Failed to remove partial outputs of video %d: %v", videoID, err)
+				}
+			}
+			refreshSubtitleMaster(ctx, videoID)
+			newProgressReporter(videoID, 0, nil).finish()
+			return nil
+		}
+	}
 
-	// --- 1. 获取视频信息 (时长和封面) ---
-	// 1.1 获取时长
-	cmdProbe := exec.Command("ffprobe", "-v", "quiet", "-print_format", "json", "-show_format", localRawPath)
-	outputProbe, err := cmdProbe.CombinedOutput()
+	// --- 1. 获取视频信息 (封面) ---
+	// 1.1 时长、分辨率和帧率已在 0.1 中读取
+	durationUint := uint(metadata.Duration)
+
+	// 1.2 截取候选封面 (跳过黑帧) 并上传，第一张作为默认封面；纯音频使用内嵌专辑封面和波形图。失败不影响转码
+	var coverCandidates []model.VideoCoverCandidate
+	if mediaType == model.MediaTypeAudio {
+		coverCandidates, err = generateAudioCovers(ctx, bucketName, videoID, localRawPath, tempDir, probe.HasStream("video"))
+	} else {
+		coverCandidates, err = generateCoverCandidates(ctx, bucketName, videoID, localRawPath, tempDir, metadata.Duration)
+	}
 	if err != nil {
-		log.Printf("ffprobe failed: %s", string(outputProbe))
-		return fmt.Errorf("ffprobe command failed: %w", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Failed to generate cover candidates for video %d: %v", videoID, err)
+	}
+	coverObjectName := ""
+	if len(coverCandidates) > 0 {
+		coverObjectName = coverCandidates[0].URL
 	}
-	var probeData ffprobeOutput
-	json.Unmarshal(outputProbe, &probeData)
-	durationFloat, _ := strconv.ParseFloat(probeData.Format.Duration, 64)
-	durationUint := uint(durationFloat)
 
-	// 1.2 截取封面图 (视频第1秒)
-	coverPath := filepath.Join(tempDir, "cover.jpg")
-	cmdCover := exec.Command("ffmpeg", "-i", localRawPath, "-ss", "00:00:01.000", "-vframes", "1", coverPath)
-	if outputCover, err := cmdCover.CombinedOutput(); err != nil {
-		log.Printf("Failed to generate cover: %s", string(outputCover))
-		// 封面生成失败不是致命错误，可以继续
+	// 1.3 生成进度条预览图 (雪碧图 + WebVTT)；纯音频改为生成波形数据。失败不影响转码
+	var thumbnailVTTObject, waveformObject string
+	if mediaType == model.MediaTypeAudio {
+		waveformObject, err = generateWaveform(ctx, bucketName, videoID, localRawPath, metadata.Duration)
+	} else {
+		thumbnailVTTObject, err = generateThumbnails(ctx, bucketName, videoID, localRawPath, tempDir, metadata)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Failed to generate thumbnails or waveform for video %d: %v", videoID, err)
 	}
 
-	// 1.3 上传封面图
-	coverObjectName := filepath.ToSlash(filepath.Join("processed", fmt.Sprintf("%d", videoID), "cover.jpg"))
-	if _, err := dal.MinioClient.FPutObject(context.Background(), bucketName, coverObjectName, coverPath, minio.PutObjectOptions{}); err != nil {
-		log.Printf("Failed to upload cover: %v", err)
-		// 上传失败也不是致命错误
+	// 1.4 响度标准化的第一遍: 测量原始音频的响度，第二遍在每个档位编码时进行。
+	// 测量失败 (例如音频全部静音) 时不做标准化
+	var loudness *model.VideoLoudness
+	audioFilter := ""
+	if config.AppConfig.FFMpeg.Loudness.Enabled && probe.HasStream("audio") {
+		target := loudnessTarget()
+		measurement, err := measureLoudness(ctx, localRawPath, target)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Failed to measure loudness of video %d, skipping normalization: %v", videoID, err)
+		} else {
+			log.Printf("Video %d loudness: %.2f LUFS, true peak %.2f dBTP, range %.2f LU",
+				videoID, measurement.IntegratedLUFS, measurement.TruePeakDBTP, measurement.LoudnessRange)
+			audioFilter = normalizationFilter(target, measurement, metadata)
+			loudness = newVideoLoudness(videoID, target, measurement)
+		}
 	}
 
 	// --- 2. 循环执行多码率转码 ---
-	profiles := config.AppConfig.FFMpeg.Profiles
-	var newVideoSources []model.VideoSource
+	// 视频按显示高度 (已考虑旋转) 选择档位，不放大；纯音频使用全部 AAC 档位
+	var renditions []rendition
+	if mediaType == model.MediaTypeAudio {
+		renditions = audioRenditions(config.AppConfig.FFMpeg.AudioProfiles, audioFilter, encryption)
+	} else {
+		renditions = videoRenditions(selectProfiles(config.AppConfig.FFMpeg.Profiles, metadata.Height), transcodeOptions{
+			SourceFrameRate: metadata.FrameRate,
+			AudioFilter:     audioFilter,
+			Watermark:       watermark,
+			Encryption:      encryption,
+		})
+	}
+	var variants []media.HLSVariant
 
-	for _, profile := range profiles {
-		outputDir := filepath.Join(tempDir, fmt.Sprintf("hls_%s", profile.Name))
+	// 进度写入 Redis，API 通过 GET /videos/:id/progress 提供给客户端
+	profileNames := make([]string, len(renditions))
+	for i, profile := range renditions {
+		profileNames[i] = profile.Name
+	}
+	progress := newProgressReporter(videoID, metadata.Duration, profileNames)
+
+	// 任务被重新投递 (重试、Worker 中途退出) 时，跳过上次已经完成的档位
+	checkpoints, err := loadProfileCheckpoints(ctx, bucketName, videoID, encrypted, watermarkKey)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoints: %w", err)
+	}
+
+	for i, profile := range renditions {
+		if cp, ok := checkpoints[profile.Name]; ok {
+			log.Printf("Profile %s of video %d already transcoded, skipping", profile.Name, videoID)
+			if cp.Variant != nil {
+				variants = append(variants, *cp.Variant)
+			}
+			progress.update(i, media.Progress{Done: true})
+			continue
+		}
+
+		// CMAF: 一次转码生成 fMP4 分片，同时写出 DASH 的 manifest.mpd 和 HLS 的 master.m3u8，两种格式共享分片。
+		// 加密时只写出 HLS 的 master.m3u8，分片为 AES-128 加密的 TS
+		dir := profileDir(profile.Name, encrypted)
+		outputDir := filepath.Join(tempDir, dir)
 		os.Mkdir(outputDir, 0755)
-		outputM3u8 := filepath.Join(outputDir, fmt.Sprintf("%s.m3u8", profile.Name))
-
-		cmdTranscode := exec.Command("ffmpeg",
-			"-i", localRawPath,
-			"-c:v", "libx264", "-c:a", "aac",
-			"-vf", "scale="+profile.Resolution,
-			"-hls_time", "10", "-hls_list_size", "0",
-			"-f", "hls", outputM3u8,
-		)
-
-		log.Printf("Executing ffmpeg for profile %s: %s", profile.Name, cmdTranscode.String())
-		if output, err := cmdTranscode.CombinedOutput(); err != nil {
-			log.Printf("FFMPEG error for profile %s: %s", profile.Name, string(output))
-			dal.DB.Model(&video).Update("status", "failed")
+
+		args := profile.Args(localRawPath, outputDir)
+		if err := runFFmpegWithProgress(ctx, args, func(p media.Progress) { progress.update(i, p) }); err != nil {
+			log.Printf("FFMPEG error for profile %s: %v", profile.Name, err)
 			return fmt.Errorf("ffmpeg command failed for profile %s: %w", profile.Name, err)
 		}
 
+		// 读取该档位的码率、分辨率和编码，用于主播放列表
+		variant, err := describeCMAFVariant(ctx, outputDir)
+		if err != nil {
+			// 不影响单独的清晰度播放，只是该档位不会出现在自适应主播放列表中
+			log.Printf("Failed to describe variant %s: %v", profile.Name, err)
+		} else {
+			variant.URI = dir + "/" + variant.URI
+			if variant.AudioURI != "" {
+				variant.AudioURI = dir + "/" + variant.AudioURI
+			}
+			variants = append(variants, *variant)
+		}
+		// 播放列表中的密钥 URI 是转码时的本地路径 (供上面的 ffprobe 读取)，上传前替换为密钥接口
+		if encryption != nil {
+			if err := encryption.rewriteKeyURI(outputDir); err != nil {
+				return fmt.Errorf("failed to rewrite key URI of profile %s: %w", profile.Name, err)
+			}
+		}
+
 		// 上传转码后的文件
-		processedPathPrefix := filepath.ToSlash(filepath.Join("processed", fmt.Sprintf("%d", videoID), fmt.Sprintf("hls_%s", profile.Name)))
+		processedPathPrefix := profileObjectPrefix(videoID, dir)
 		files, _ := os.ReadDir(outputDir)
 		var totalSize uint64 // <-- 新增：用于累加文件大小
+		var dashSize uint64  // manifest.mpd 的大小，单独记在 DASH 播放源上
 		for _, file := range files {
 			localFilePath := filepath.Join(outputDir, file.Name())
 
@@ -119,27 +487,62 @@ func HandleTranscode(videoID uint64) error {
 			fileInfo, err := os.Stat(localFilePath)
 			if err == nil {
 				totalSize += uint64(fileInfo.Size()) // <-- 新增：累加大小
+				if file.Name() == cmafDashManifest {
+					dashSize = uint64(fileInfo.Size())
+				}
 			}
 
-			_, err = dal.MinioClient.FPutObject(context.Background(), bucketName,
+			_, err = dal.MinioClient.FPutObject(ctx, bucketName,
 				filepath.ToSlash(filepath.Join(processedPathPrefix, file.Name())),
 				localFilePath,
 				minio.PutObjectOptions{},
 			)
 			if err != nil {
-				dal.DB.Model(&video).Update("status", "failed")
-				return fmt.Errorf("failed to upload HLS file %s: %w", file.Name(), err)
+				return fmt.Errorf("failed to upload CMAF file %s: %w", file.Name(), err)
 			}
 		}
 
-		// 准备要写入数据库的 video_source
-		newVideoSources = append(newVideoSources, model.VideoSource{
+		// 准备要写入数据库的 video_source: 每个清晰度一条 HLS、一条 DASH (加密时只有 HLS)。
+		// 两者共享分片，分片大小只计入 HLS 记录，避免配额统计重复计算
+		hlsSource := model.VideoSource{
 			VideoID:  video.ID,
 			Quality:  profile.Name,
-			Format:   "HLS",
-			URL:      filepath.ToSlash(filepath.Join(processedPathPrefix, fmt.Sprintf("%s.m3u8", profile.Name))),
-			FileSize: totalSize, // <-- 新增：填充文件大小
-		})
+			Format:   model.SourceFormatHLS,
+			URL:      processedPathPrefix + "/" + cmafHLSPlaylist,
+			FileSize: totalSize - dashSize,
+			// 记录水印指纹，重试时据此判断检查点是否仍然可用
+			WatermarkKey: watermarkKey,
+		}
+		if variant != nil {
+			hlsSource.Bandwidth = variant.Bandwidth
+			hlsSource.Width = variant.Width
+			hlsSource.Height = variant.Height
+			hlsSource.Codecs = variant.Codecs
+			hlsSource.FrameRate = variant.FrameRate
+		}
+		profileSources := []model.VideoSource{hlsSource}
+		if !encrypted {
+			dashSource := hlsSource
+			dashSource.Format = model.SourceFormatDASH
+			dashSource.URL = processedPathPrefix + "/" + cmafDashManifest
+			dashSource.FileSize = dashSize
+			profileSources = append(profileSources, dashSource)
+		}
+
+		// 检查点: 该档位的文件已全部上传，立即写入播放源，重试时据此跳过
+		if err := upsertVideoSources(dal.DB, profileSources); err != nil {
+			return fmt.Errorf("failed to save sources of profile %s: %w", profile.Name, err)
+		}
+	}
+
+	// --- 2.1 生成自适应码率主播放列表 processed/<id>/master.m3u8，记为 auto 播放源 ---
+	var masterSources []model.VideoSource
+	if len(variants) > 0 {
+		masterSource, err := uploadMasterPlaylist(ctx, bucketName, videoID, variants)
+		if err != nil {
+			return err
+		}
+		masterSources = append(masterSources, *masterSource)
 	}
 
 	// --- 3. 使用数据库事务，一次性更新所有信息 ---
@@ -148,23 +551,104 @@ func HandleTranscode(videoID uint64) error {
 		return tx.Error
 	}
 
-	// 3.1 更新主视频表信息 (时长, 封面, 状态)
+	// 3.1 更新主视频表信息 (时长, 封面, 预览图, 媒体类型, 状态)
 	updates := map[string]interface{}{
-		"status":    "online",
-		"duration":  durationUint,
-		"cover_url": filepath.ToSlash(coverObjectName),
+		"status":            "online",
+		"duration":          durationUint,
+		"cover_url":         coverObjectName,
+		"thumbnail_vtt_url": thumbnailVTTObject,
+		"media_type":        mediaType,
+		"waveform_url":      waveformObject,
 	}
 	if err := tx.Model(&video).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 3.2 批量创建视频源记录
-	if err := tx.Create(&newVideoSources).Error; err != nil {
+	// 3.2 登记转码产物，之后内容相同的上传会复用它
+	newAsset := model.MediaAsset{
+		ContentHash:   contentHash,
+		WatermarkKey:  watermarkKey,
+		Encrypted:     encrypted,
+		StoragePrefix: fmt.Sprintf("processed/%d", videoID),
+		RefCount:      1,
+	}
+	if err := tx.Create(&newAsset).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Model(&video).Updates(map[string]interface{}{
+		"content_hash": contentHash,
+		"asset_id":     newAsset.ID,
+	}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
+	// 3.3 写入主播放列表，并删除不再属于本次转码结果的播放源 (例如档位配置已修改)
+	if err := upsertVideoSources(tx, masterSources); err != nil {
+		tx.Rollback()
+		return err
+	}
+	keep := append([]string{}, profileNames...)
+	if len(masterSources) > 0 {
+		keep = append(keep, model.SourceQualityAuto)
+	}
+	if err := tx.Where("video_id = ? AND quality NOT IN ?", videoID, keep).Delete(&model.VideoSource{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	// 加密的视频没有 DASH 播放源，删除之前未加密时转码留下的记录
+	if encrypted {
+		if err := tx.Where("video_id = ? AND format = ?", videoID, model.SourceFormatDASH).Delete(&model.VideoSource{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// 3.4 替换候选封面 (任务重复执行时先删除上次的记录)
+	if err := tx.Where("video_id = ?", videoID).Delete(&model.VideoCoverCandidate{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(coverCandidates) > 0 {
+		if err := tx.Create(&coverCandidates).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// 3.5 保存响度测量结果，未做标准化时删除上次的记录
+	if err := tx.Where("video_id = ?", videoID).Delete(&model.VideoLoudness{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if loudness != nil {
+		if err := tx.Create(loudness).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// 3.6 未加密时删除之前加密转码时生成的内容密钥，详情接口据此不再签发播放令牌
+	if !encrypted {
+		if err := tx.Where("video_id = ?", videoID).Delete(&model.VideoKey{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
 	log.Println("Successfully updated database in a transaction.")
-	return tx.Commit().Error
+	// 切换过加密配置时，另一种格式的档位目录已经不再被引用；未加密的旧文件仍可公开访问，需要删除
+	for _, name := range profileNames {
+		if err := service.RemoveObjectsWithPrefix(ctx, profileObjectPrefix(videoID, profileDir(name, !encrypted))); err != nil {
+			log.Printf("Failed to remove stale outputs of profile %s of video %d: %v", name, videoID, err)
+		}
+	}
+	refreshSubtitleMaster(ctx, videoID)
+	progress.finish()
+	return nil
 }
@@ -0,0 +1,31 @@
+// internal/worker/upload_events.go
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// HandleUploadEventDelivery 处理 MinIO 通过 AMQP 目标投递的一条存储桶事件，
+// 对 raw/ 下上传完成的原始文件执行和 webhook 相同的自动完成逻辑。
+// 与视频不匹配的对象和校验、配额失败 (视频已标记为 failed) 直接确认；数据库、MinIO 等暂时性错误稍后退回队列重试
+func HandleUploadEventDelivery(ctx context.Context, d amqp091.Delivery) {
+	var event service.MinioEvent
+	if err := json.Unmarshal(d.Body, &event); err != nil {
+		log.Printf("Error unmarshalling MinIO event: %s", err)
+		d.Nack(false, false) // 消息格式错误，直接丢弃
+		return
+	}
+
+	// HandleMinioEventService 只对暂时性错误返回 error，永久性的失败已在其中处理
+	if err := service.HandleMinioEventService(event); err != nil {
+		log.Printf("Failed to handle MinIO event %s, requeueing in %s: %v", event.Key, transientRetryDelay, err)
+		requeueAfterDelay(ctx, d)
+		return
+	}
+	d.Ack(false)
+}
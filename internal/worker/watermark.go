@@ -0,0 +1,68 @@
+// internal/worker/watermark.go
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"path/filepath"
+
+	"github.com/cjh/video-platform-go/internal/dal"
+	"github.com/cjh/video-platform-go/internal/dal/model"
+	"github.com/cjh/video-platform-go/internal/service"
+	"github.com/minio/minio-go/v7"
+)
+
+// watermarkOverlay 是下载到本地的水印图片及其样式
+type watermarkOverlay struct {
+	service.Watermark
+	Path string // 本地图片路径，作为 ffmpeg 的第二个输入
+}
+
+// prepareWatermark 按上传者当前的角色和个人设置确定水印，并把图片下载到 tempDir。
+// 返回水印和它的指纹 (用于转码产物去重)，不叠加水印时返回 nil 和空字符串
+func prepareWatermark(ctx context.Context, bucketName string, video *model.Video, tempDir string) (*watermarkOverlay, string, error) {
+	var uploader model.User
+	if err := dal.DB.First(&uploader, video.UserID).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to load uploader %d: %w", video.UserID, err)
+	}
+	watermark, err := service.ResolveWatermark(uploader.ID, uploader.Role)
+	if err != nil || watermark == nil {
+		return nil, "", err
+	}
+
+	info, err := dal.MinioClient.StatObject(ctx, bucketName, watermark.Image, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to stat watermark image %s: %w", watermark.Image, err)
+	}
+	localPath := filepath.Join(tempDir, "watermark"+path.Ext(watermark.Image))
+	if err := dal.MinioClient.FGetObject(ctx, bucketName, watermark.Image, localPath, minio.GetObjectOptions{}); err != nil {
+		return nil, "", fmt.Errorf("failed to download watermark image %s: %w", watermark.Image, err)
+	}
+	return &watermarkOverlay{Watermark: *watermark, Path: localPath}, watermark.Fingerprint(info.ETag), nil
+}
+
+// filterComplex 返回叠加水印的 -filter_complex: 原始视频 (输入 0) 经过 videoFilters 后，
+// 在指定位置叠加缩放到输出高度一定比例并调整了不透明度的水印 (输入 1)，输出为 [v]
+func (w *watermarkOverlay) filterComplex(videoFilters string, outputHeight int) string {
+	margin := int(math.Round(float64(outputHeight) * w.Margin))
+	logoHeight := max(2, int(math.Round(float64(outputHeight)*w.Scale/2))*2)
+
+	right := fmt.Sprintf("main_w-overlay_w-%d", margin)
+	bottom := fmt.Sprintf("main_h-overlay_h-%d", margin)
+	x, y := right, bottom
+	switch w.Position {
+	case "top-left":
+		x, y = fmt.Sprint(margin), fmt.Sprint(margin)
+	case "top-right":
+		y = fmt.Sprint(margin)
+	case "bottom-left":
+		x = fmt.Sprint(margin)
+	case "center":
+		x, y = "(main_w-overlay_w)/2", "(main_h-overlay_h)/2"
+	}
+
+	return fmt.Sprintf("[0:v:0]%s[base];[1:v]scale=-1:%d,format=rgba,colorchannelmixer=aa=%g[wm];[base][wm]overlay=x=%s:y=%s[v]",
+		videoFilters, logoHeight, w.Opacity, x, y)
+}
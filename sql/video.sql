@@ -21,30 +21,194 @@ CREATE TABLE `videos` (
   `user_id` BIGINT UNSIGNED NOT NULL,
   `title` VARCHAR(255) NOT NULL,
   `description` TEXT,
-  `original_file_name` VARCHAR(255) NOT NULL,
-  `status` ENUM('uploading', 'transcoding', 'online', 'failed', 'private') NOT NULL DEFAULT 'uploading',
+  `original_file_name` VARCHAR(255) NOT NULL COMMENT '清理后的原始文件名，仅用于展示',
+  `object_key` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '服务端生成的原始文件对象路径, 例如 raw/1/9f86d081884c7d65.mp4',
+  `raw_size` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '原始文件大小 (字节)，完成上传时记录，用于配额统计；原始文件被清理后归零',
+  `status` ENUM('uploading', 'transcoding', 'online', 'failed', 'dead_lettered', 'private') NOT NULL DEFAULT 'uploading' COMMENT 'dead_lettered: 转码任务在死信队列中，保留原始文件以便重新投递',
   `duration` INT UNSIGNED COMMENT '视频时长，单位秒',
-  `cover_url` VARCHAR(1024),
+  `transcode_attempts` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '转码已失败的次数，reaper 重新提交卡住的转码时沿用，达到上限后转入死信队列',
+  `cover_url` VARCHAR(1024) COMMENT '封面对象路径: 候选封面 processed/<id>/covers/... 或自定义封面 covers/<id>/...',
+  `thumbnail_vtt_url` VARCHAR(1024) COMMENT '进度条预览图的 WebVTT 文件, 例如 processed/1/thumbs/thumbnails.vtt',
+  `media_type` ENUM('video', 'audio') NOT NULL DEFAULT 'video' COMMENT '转码时识别: 没有视频流的上传为 audio',
+  `waveform_url` VARCHAR(1024) COMMENT '纯音频的波形数据, 例如 processed/1/waveform.json',
+  `content_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '原始文件的 SHA-256，用于识别重复上传',
+  `asset_id` BIGINT UNSIGNED NULL COMMENT '使用的转码产物 (media_assets)，内容相同的视频共享',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
+  INDEX `idx_content_hash` (`content_hash`),
   FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB;
 
--- 视频源表 (多清晰度)
+-- 转码产物表: processed/<id>/ 下的一套转码结果，按原始文件内容去重，被多个视频引用
+CREATE TABLE `media_assets` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `content_hash` VARCHAR(64) NOT NULL COMMENT '原始文件的 SHA-256',
+  `watermark_key` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '叠加的水印 (图片和样式) 的指纹，没有水印时为空；水印不同的产物不能共享',
+  `encrypted` BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'HLS 分片使用视频自己的内容密钥加密，这样的产物不能共享',
+  `storage_prefix` VARCHAR(255) NOT NULL COMMENT '转码产物所在的目录, 例如 processed/1',
+  `ref_count` INT UNSIGNED NOT NULL DEFAULT 1 COMMENT '引用该产物的视频数，为 0 时删除对象',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `idx_content_hash` (`content_hash`)
+) ENGINE=InnoDB;
+
+-- 视频元数据表: 原始文件的 ffprobe 信息，每个视频一条
+CREATE TABLE `video_metadata` (
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `container` VARCHAR(100) NOT NULL COMMENT '容器格式, 例如 mov,mp4,m4a,3gp,3g2,mj2',
+  `duration` DOUBLE NOT NULL DEFAULT 0 COMMENT '时长 (秒)',
+  `bit_rate` BIGINT NOT NULL DEFAULT 0 COMMENT '总码率 (bit/s)',
+  `video_codec` VARCHAR(50) NOT NULL DEFAULT '',
+  `audio_codec` VARCHAR(50) NOT NULL DEFAULT '',
+  `width` INT NOT NULL DEFAULT 0 COMMENT '按旋转修正后的显示宽度',
+  `height` INT NOT NULL DEFAULT 0 COMMENT '按旋转修正后的显示高度',
+  `frame_rate` DECIMAL(8,3) NOT NULL DEFAULT 0,
+  `rotation` INT NOT NULL DEFAULT 0 COMMENT '顺时针旋转角度 0/90/180/270',
+  `creation_time` TIMESTAMP NULL COMMENT '容器中记录的创建时间',
+  `streams` JSON NULL COMMENT '每个流的编码、分辨率、帧率、码率、像素格式、声道、采样率等',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 响度表: 转码时 loudnorm 第一遍测量的原始音频响度 (EBU R128)，有记录说明播放源已做响度标准化
+CREATE TABLE `video_loudness` (
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `integrated_lufs` DECIMAL(6,2) NOT NULL COMMENT '原始音频的综合响度 (LUFS)',
+  `true_peak_dbtp` DECIMAL(6,2) NOT NULL COMMENT '原始音频的真峰值 (dBTP)',
+  `loudness_range` DECIMAL(6,2) NOT NULL COMMENT '原始音频的响度范围 (LU)',
+  `threshold` DECIMAL(6,2) NOT NULL COMMENT 'loudnorm 测量的门限',
+  `target_lufs` DECIMAL(6,2) NOT NULL COMMENT '标准化目标 (LUFS)',
+  `replay_gain_db` DECIMAL(6,2) NOT NULL COMMENT '播放源相对 ReplayGain 参考响度 (-18 LUFS) 需要的增益',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 候选封面表: 转码时截取的非黑帧，所有者可以从中选择封面
+CREATE TABLE `video_cover_candidates` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `url` VARCHAR(1024) NOT NULL COMMENT '对象路径, 例如 processed/1/covers/candidate-1.jpg',
+  `offset` DECIMAL(10,3) NOT NULL DEFAULT 0 COMMENT '截取位置 (秒)',
+  `brightness` DECIMAL(6,2) NOT NULL DEFAULT 0 COMMENT '平均亮度 0-255',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_video_id` (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 字幕表: 每个视频每种语言一条，上传的 SRT / WebVTT / ASS 统一转换为 WebVTT
+CREATE TABLE `video_subtitles` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `language` VARCHAR(35) NOT NULL COMMENT 'BCP 47 语言标签, 例如 en, zh-CN',
+  `label` VARCHAR(100) NOT NULL COMMENT '播放器中显示的名称',
+  `source_format` VARCHAR(10) NOT NULL COMMENT '上传的格式: srt / vtt / ass / ssa',
+  `url` VARCHAR(1024) NOT NULL COMMENT 'WebVTT 对象路径, 例如 subtitles/1/en.vtt',
+  `playlist_url` VARCHAR(1024) NOT NULL COMMENT 'HLS 字幕媒体播放列表, 例如 subtitles/1/en.m3u8',
+  `is_default` BOOLEAN NOT NULL DEFAULT FALSE,
+  `cue_count` INT NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_language` (`video_id`, `language`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 视频源表 (多清晰度；quality 为 auto 的记录是自适应码率主播放列表)
 CREATE TABLE `video_sources` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
   `video_id` BIGINT UNSIGNED NOT NULL,
   `quality` VARCHAR(20) NOT NULL COMMENT '例如: 360p, 720p, 1080p',
   `format` VARCHAR(20) NOT NULL COMMENT '例如: HLS, DASH, MP4',
-  `url` VARCHAR(1024) NOT NULL COMMENT '播放地址, M3U8文件或MP4文件',
+  `url` VARCHAR(1024) NOT NULL COMMENT '播放地址, M3U8 / MPD 文件或 MP4 文件 (HLS 和 DASH 共享同一组 CMAF 分片)',
   `file_size` BIGINT UNSIGNED COMMENT '文件大小，单位字节',
+  `bandwidth` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '峰值码率 (bit/s)，对应 HLS 的 BANDWIDTH',
+  `width` INT UNSIGNED NOT NULL DEFAULT 0,
+  `height` INT UNSIGNED NOT NULL DEFAULT 0,
+  `codecs` VARCHAR(100) NOT NULL DEFAULT '' COMMENT 'RFC 6381 编码字符串, 例如 avc1.64001f,mp4a.40.2',
+  `frame_rate` DECIMAL(6,3) NOT NULL DEFAULT 0,
+  `watermark_key` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '转码该档位时叠加的水印的指纹，没有水印时为空；水印不同的检查点在重试时不复用',
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `uk_video_quality` (`video_id`, `quality`),
+  UNIQUE KEY `uk_video_quality_format` (`video_id`, `quality`, `format`),
   FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
 ) ENGINE=InnoDB;
 
+-- 分片上传会话表 (MinIO multipart upload)
+CREATE TABLE `upload_sessions` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `upload_id` VARCHAR(255) NOT NULL COMMENT 'MinIO 返回的 uploadId',
+  `object_key` VARCHAR(1024) NOT NULL COMMENT '合并后的对象路径, 例如 raw/1/movie.mp4',
+  `part_size` BIGINT UNSIGNED NOT NULL COMMENT '分片大小，单位字节',
+  `file_size` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '客户端声明的文件总大小，0 表示未知',
+  `protocol` ENUM('multipart', 'tus') NOT NULL DEFAULT 'multipart' COMMENT '上传方式: 预签名分片 或 tus 协议',
+  `received_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT 'tus 上传已持久化的字节数 (Upload-Offset)',
+  `status` ENUM('active', 'completed', 'aborted') NOT NULL DEFAULT 'active',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_id` (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 远程 URL 导入任务表
+CREATE TABLE `video_imports` (
+  `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `source_url` VARCHAR(2048) NOT NULL COMMENT '导入源地址',
+  `status` ENUM('pending', 'downloading', 'completed', 'failed') NOT NULL DEFAULT 'pending',
+  `total_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '源站返回的 Content-Length，0 表示未知',
+  `received_bytes` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '已写入 MinIO 的字节数',
+  `error` VARCHAR(1024) NOT NULL DEFAULT '' COMMENT '失败原因',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_video_id` (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 用户配额覆盖表 (为空的字段沿用 config.yaml 中按角色配置的默认值，0 表示不限制)
+CREATE TABLE `user_quotas` (
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `max_storage_bytes` BIGINT UNSIGNED NULL COMMENT '原始文件 + 转码产物的总存储上限，单位字节',
+  `max_videos_per_day` INT UNSIGNED NULL COMMENT '每天最多创建的视频数',
+  `max_duration_seconds` INT UNSIGNED NULL COMMENT '单个视频的最长时长，单位秒',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`),
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 内容密钥表: 加密 HLS 使用的 AES-128 密钥，每个视频一个，由 Worker 生成
+CREATE TABLE `video_keys` (
+  `video_id` BIGINT UNSIGNED NOT NULL,
+  `encrypted_key` VARBINARY(64) NOT NULL COMMENT '用 encryption.master_key (AES-256-GCM) 加密的密钥: nonce || 密文',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`video_id`),
+  FOREIGN KEY (`video_id`) REFERENCES `videos`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- 个人水印设置: 覆盖 watermark 配置中的图片和样式，NULL 表示沿用平台默认值
+CREATE TABLE `user_watermarks` (
+  `user_id` BIGINT UNSIGNED NOT NULL,
+  `image` VARCHAR(255) NULL COMMENT '水印图片在桶中的对象路径',
+  `position` VARCHAR(20) NULL COMMENT 'top-left / top-right / bottom-left / bottom-right / center',
+  `margin` DOUBLE NULL COMMENT '到画面边缘的距离，相对输出高度的比例',
+  `opacity` DOUBLE NULL COMMENT '不透明度 (0, 1]',
+  `scale` DOUBLE NULL COMMENT '水印高度相对输出高度的比例',
+  `opt_out` BOOLEAN NOT NULL DEFAULT FALSE COMMENT '上传者关闭水印，角色在 watermark.opt_out_roles 中时生效',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`),
+  FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
 -- 评论/弹幕表
 CREATE TABLE `comments` (
   `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,